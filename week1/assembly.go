@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// AssemblyStats summarizes an assembly, like QUAST does.
+type AssemblyStats struct {
+	Sample      string `json:"sample"`
+	Contigs     int    `json:"contigs"`
+	TotalLength int    `json:"totalLength"`
+	Largest     int    `json:"largest"`
+	// N50 is the length of the shortest contig among the longest contigs making up half the assembly,
+	// and L50 the number of those contigs. N90 and L90 are the same for 90% of the assembly.
+	N50 int `json:"n50"`
+	L50 int `json:"l50"`
+	N90 int `json:"n90"`
+	L90 int `json:"l90"`
+	// GC is the percentage of G and C among the A, C, G and T bases.
+	GC     float64 `json:"gc"`
+	NCount int     `json:"nCount"`
+	// MeanCoverage and MedianCoverage are weighted by contig length,
+	// counting only contigs with coverage in their SPAdes header.
+	MeanCoverage   float64 `json:"meanCoverage"`
+	MedianCoverage float64 `json:"medianCoverage"`
+}
+
+// NewAssemblyStats computes the statistics of the contigs of sample passing filter.
+func NewAssemblyStats(sample string, records []Fasta, filter ContigFilter) AssemblyStats {
+	stats := AssemblyStats{Sample: sample}
+
+	lengths := []int{}
+	covered := []ContigHeader{}
+	gc, acgt := 0, 0
+	for i := range records {
+		record := &records[i]
+		header := contigHeader(record)
+		genome := record.Genome()
+		if len(genome) == 0 || !filter.Accept(header, len(genome)) {
+			continue
+		}
+
+		lengths = append(lengths, len(genome))
+		if header.Coverage > 0 {
+			header.Length = len(genome)
+			covered = append(covered, header)
+		}
+
+		for j := 0; j < len(genome); j++ {
+			switch genome[j] {
+			case 'G', 'C', 'g', 'c':
+				gc++
+				acgt++
+			case 'A', 'T', 'a', 't':
+				acgt++
+			case 'N', 'n':
+				stats.NCount++
+			}
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(lengths)))
+	stats.Contigs = len(lengths)
+	for _, length := range lengths {
+		stats.TotalLength += length
+	}
+	if len(lengths) > 0 {
+		stats.Largest = lengths[0]
+	}
+	stats.N50, stats.L50 = nx(lengths, stats.TotalLength, 50)
+	stats.N90, stats.L90 = nx(lengths, stats.TotalLength, 90)
+	if acgt > 0 {
+		stats.GC = 100 * float64(gc) / float64(acgt)
+	}
+	stats.MeanCoverage, stats.MedianCoverage = weightedCoverage(covered)
+
+	return stats
+}
+
+// nx finds Nx and Lx of contig lengths sorted longest first, where x is a percentage of total.
+func nx(lengths []int, total, x int) (n, l int) {
+	sum := 0
+	for i, length := range lengths {
+		sum += length
+		// sum/total >= x/100, in integers
+		if 100*sum >= x*total {
+			return length, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// weightedCoverage is the mean and median coverage per base.
+func weightedCoverage(contigs []ContigHeader) (mean, median float64) {
+	if len(contigs) == 0 {
+		return 0, 0
+	}
+
+	sorted := make([]ContigHeader, len(contigs))
+	copy(sorted, contigs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Coverage < sorted[j].Coverage
+	})
+
+	total := 0
+	for _, contig := range sorted {
+		total += contig.Length
+		mean += contig.Coverage * float64(contig.Length)
+	}
+	mean /= float64(total)
+
+	sum := 0
+	for _, contig := range sorted {
+		sum += contig.Length
+		if 2*sum >= total {
+			median = contig.Coverage
+			break
+		}
+	}
+	return mean, median
+}
+
+// SortAssemblyStats sorts the best assemblies first: highest N50, then fewest contigs, then by sample.
+func SortAssemblyStats(stats []AssemblyStats) {
+	sort.SliceStable(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		if a.N50 != b.N50 {
+			return a.N50 > b.N50
+		}
+		if a.Contigs != b.Contigs {
+			return a.Contigs < b.Contigs
+		}
+		return a.Sample < b.Sample
+	})
+}
+
+// fastaExtensions are the file extensions read as assemblies from a directory.
+var fastaExtensions = map[string]bool{".fasta": true, ".fa": true, ".fna": true}
+
+// AssemblyStatsDir computes the statistics of every FASTA file in dir, best assembly first.
+func AssemblyStatsDir(dir string, filter ContigFilter) ([]AssemblyStats, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	filenames := []string{}
+	for _, file := range files {
+		if !file.IsDir() && fastaExtensions[strings.ToLower(filepath.Ext(file.Name()))] {
+			filenames = append(filenames, filepath.Join(dir, file.Name()))
+		}
+	}
+	return assemblyStatsFiles(filenames, filter)
+}
+
+func assemblyStatsFiles(filenames []string, filter ContigFilter) ([]AssemblyStats, error) {
+	stats := []AssemblyStats{}
+	for _, filename := range filenames {
+		records, err := ReadFastaRecords(filename)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, NewAssemblyStats(sampleName(filename), records, filter))
+	}
+
+	SortAssemblyStats(stats)
+	return stats, nil
+}
+
+// WriteAssemblyStatsTSV writes one line per assembly.
+func WriteAssemblyStatsTSV(w io.Writer, stats []AssemblyStats) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "sample\tcontigs\ttotal_length\tlargest\tn50\tl50\tn90\tl90\tgc\tn_count\tmean_cov\tmedian_cov")
+	for _, s := range stats {
+		fmt.Fprintf(out, "%v\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%.2f\t%d\t%.2f\t%.2f\n",
+			s.Sample, s.Contigs, s.TotalLength, s.Largest, s.N50, s.L50, s.N90, s.L90,
+			s.GC, s.NCount, s.MeanCoverage, s.MedianCoverage)
+	}
+	return out.Flush()
+}
+
+// WriteAssemblyStatsJSON writes the statistics as a JSON array.
+func WriteAssemblyStatsJSON(w io.Writer, stats []AssemblyStats) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(stats)
+}
+
+// qcCommand writes the statistics of the assemblies in a directory, or of the given files, to stdout.
+//
+//	qc [-min-length 0] [-json] fasta/
+func qcCommand(args []string) error {
+	filter := ContigFilter{}
+	flags := flag.NewFlagSet("qc", flag.ContinueOnError)
+	flags.IntVar(&filter.MinLength, "min-length", 0, "leave out contigs shorter than this")
+	flags.Float64Var(&filter.MinCoverage, "min-cov", 0, "leave out contigs with lower coverage than this")
+	asJSON := flags.Bool("json", false, "write JSON instead of TSV")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("usage: qc [flags] directory | assembly.fasta...")
+	}
+
+	var stats []AssemblyStats
+	var err error
+	if info, statErr := os.Stat(flags.Arg(0)); statErr == nil && info.IsDir() && flags.NArg() == 1 {
+		stats, err = AssemblyStatsDir(flags.Arg(0), filter)
+	} else {
+		stats, err = assemblyStatsFiles(flags.Args(), filter)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return WriteAssemblyStatsJSON(os.Stdout, stats)
+	}
+	return WriteAssemblyStatsTSV(os.Stdout, stats)
+}
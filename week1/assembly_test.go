@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAssemblyStats(t *testing.T) {
+	records, err := ParseFasta(strings.NewReader(
+		">NODE_1_length_10_cov_20\nGGGGGCCCAA\n" +
+			">NODE_2_length_8_cov_10\nATATNNAT\n" +
+			">NODE_3_length_6_cov_5\nACGTAC\n" +
+			">NODE_4_length_4_cov_100\nAAAA\n" +
+			">unknown\nGC\n"))
+	assert.NoError(t, err)
+
+	stats := NewAssemblyStats("sample", records, ContigFilter{})
+	assert.Equal(t, "sample", stats.Sample)
+	assert.Equal(t, 5, stats.Contigs)
+	assert.Equal(t, 30, stats.TotalLength)
+	assert.Equal(t, 10, stats.Largest)
+	// 10 + 8 = 18 >= 15, and 10 + 8 + 6 + 4 = 28 >= 27
+	assert.Equal(t, 8, stats.N50)
+	assert.Equal(t, 2, stats.L50)
+	assert.Equal(t, 4, stats.N90)
+	assert.Equal(t, 4, stats.L90)
+	assert.Equal(t, 2, stats.NCount)
+	// 8 + 0 + 3 + 0 + 2 G or C of 28 ACGT
+	assert.InDelta(t, 100*13.0/28, stats.GC, 1e-9)
+	// the contig without coverage is left out
+	assert.InDelta(t, (10*20+8*10+6*5+4*100)/28.0, stats.MeanCoverage, 1e-9)
+	assert.Equal(t, 10.0, stats.MedianCoverage)
+
+	stats = NewAssemblyStats("sample", records, ContigFilter{MinLength: 6})
+	assert.Equal(t, 3, stats.Contigs)
+	assert.Equal(t, 24, stats.TotalLength)
+	assert.Equal(t, 8, stats.N50)
+}
+
+func TestNewAssemblyStatsEmpty(t *testing.T) {
+	stats := NewAssemblyStats("empty", []Fasta{}, ContigFilter{})
+	assert.Equal(t, AssemblyStats{Sample: "empty"}, stats)
+}
+
+func TestNx(t *testing.T) {
+	n, l := nx([]int{100}, 100, 50)
+	assert.Equal(t, 100, n)
+	assert.Equal(t, 1, l)
+
+	n, l = nx([]int{5, 5, 5, 5}, 20, 50)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, 2, l)
+}
+
+func TestAssemblyStatsDir(t *testing.T) {
+	stats, err := AssemblyStatsDir("fasta", ContigFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, stats, 25)
+
+	for i := 1; i < len(stats); i++ {
+		assert.True(t, stats[i-1].N50 >= stats[i].N50)
+	}
+	for _, s := range stats {
+		if s.Sample == "25_S4" {
+			assert.Equal(t, 20, s.Contigs)
+			assert.True(t, s.MeanCoverage > 0)
+		}
+	}
+}
+
+func TestWriteAssemblyStats(t *testing.T) {
+	stats := []AssemblyStats{{Sample: "a", Contigs: 1, TotalLength: 4, Largest: 4, N50: 4, L50: 1, N90: 4, L90: 1, GC: 50, MeanCoverage: 2.5, MedianCoverage: 2.5}}
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, WriteAssemblyStatsTSV(&buf, stats))
+	assert.Equal(t, "sample\tcontigs\ttotal_length\tlargest\tn50\tl50\tn90\tl90\tgc\tn_count\tmean_cov\tmedian_cov\n"+
+		"a\t1\t4\t4\t4\t1\t4\t1\t50.00\t0\t2.50\t2.50\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, WriteAssemblyStatsJSON(&buf, stats))
+	read := []AssemblyStats{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &read))
+	assert.Equal(t, stats, read)
+}
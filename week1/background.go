@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+)
+
+// Background is a model of random DNA, used to tell how surprising a word or a motif is.
+type Background interface {
+	// WordProbability is the probability of word occurring at a given position.
+	WordProbability(word sequence) float64
+	// LogLikelihood is the natural logarithm of WordProbability, without underflow for long sequences.
+	LogLikelihood(seq sequence) float64
+}
+
+// MarkovModel is a Markov chain background of order 0 and up.
+// Order 0 is the i.i.d. model, where every base is drawn from the same base frequencies.
+type MarkovModel struct {
+	Order int `json:"order"`
+	// Initial is the probability of each word of Order bases, indexed by PatternToIndex.
+	Initial []float64 `json:"initial"`
+	// Transitions is the probability of a base following a context of Order bases,
+	// indexed by PatternToIndex(context)*4 + base.
+	Transitions []float64 `json:"transitions"`
+}
+
+// NewMarkovModel estimates a Markov model of the given order from normalized DNA,
+// with a pseudocount of 1 for every word, so no word has zero probability.
+func NewMarkovModel(normDNA []byte, order int) *MarkovModel {
+	return TrainMarkovModel(sequences{normDNA}, order, 1)
+}
+
+// TrainMarkovModel estimates a Markov model of the given order from the words in seqs.
+// The pseudocount is added to the count of every word before the counts are turned into probabilities.
+func TrainMarkovModel(seqs sequences, order int, pseudocount float64) *MarkovModel {
+	initial := make([]float64, Pow4(order))
+	transitions := make([]float64, Pow4(order+1))
+	for i := range initial {
+		initial[i] = pseudocount
+	}
+	for i := range transitions {
+		transitions[i] = pseudocount
+	}
+
+	for _, seq := range seqs {
+		for i := 0; i <= len(seq)-order; i++ {
+			initial[PatternToIndex(seq[i:i+order])]++
+		}
+		for i := 0; i <= len(seq)-order-1; i++ {
+			transitions[PatternToIndex(seq[i:i+order+1])]++
+		}
+	}
+
+	normalize(initial)
+	for context := 0; context < len(initial); context++ {
+		normalize(transitions[context*4 : context*4+4])
+	}
+
+	return &MarkovModel{
+		Order:       order,
+		Initial:     initial,
+		Transitions: transitions,
+	}
+}
+
+// ReadMarkovModel reads a model written by Write.
+func ReadMarkovModel(r io.Reader) (*MarkovModel, error) {
+	model := MarkovModel{}
+	if err := json.NewDecoder(r).Decode(&model); err != nil {
+		return nil, err
+	}
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+// Write writes the model as JSON.
+func (m *MarkovModel) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(m)
+}
+
+// Validate checks that the tables have the right size for the order, and hold probabilities summing to 1.
+func (m *MarkovModel) Validate() error {
+	if m.Order < 0 {
+		return fmt.Errorf("order must be at least 0, got %v", m.Order)
+	}
+	if len(m.Initial) != int(Pow4(m.Order)) || len(m.Transitions) != int(Pow4(m.Order+1)) {
+		return fmt.Errorf("tables do not match order %v: %v initial and %v transitions", m.Order, len(m.Initial), len(m.Transitions))
+	}
+	if !sumsToOne(m.Initial) {
+		return errors.New("initial probabilities do not sum to 1")
+	}
+	for context := 0; context < len(m.Initial); context++ {
+		if !sumsToOne(m.Transitions[context*4 : context*4+4]) {
+			return fmt.Errorf("transitions from context %v do not sum to 1", context)
+		}
+	}
+	return nil
+}
+
+func (m *MarkovModel) WordProbability(word sequence) float64 {
+	if len(word) <= m.Order {
+		// sum over all the starting words of Order bases having word as prefix
+		size := int(Pow4(m.Order - len(word)))
+		first := PatternToIndex(word) * size
+		sum := 0.0
+		for i := first; i < first+size; i++ {
+			sum += m.Initial[i]
+		}
+		return sum
+	}
+
+	p := m.Initial[PatternToIndex(word[:m.Order])]
+	for i := m.Order; i < len(word); i++ {
+		p *= m.transition(word[i-m.Order:i], word[i])
+	}
+	return p
+}
+
+func (m *MarkovModel) LogLikelihood(seq sequence) float64 {
+	if len(seq) <= m.Order {
+		return math.Log(m.WordProbability(seq))
+	}
+
+	logP := math.Log(m.Initial[PatternToIndex(seq[:m.Order])])
+	for i := m.Order; i < len(seq); i++ {
+		logP += math.Log(m.transition(seq[i-m.Order:i], seq[i]))
+	}
+	return logP
+}
+
+// Sample draws a random sequence of length n from the model.
+func (m *MarkovModel) Sample(n int, random *rand.Rand) sequence {
+	seq := make(sequence, 0, Max(n, m.Order))
+
+	if m.Order > 0 {
+		start := sampleIndex(m.Initial, random)
+		seq = append(seq, NormalizeDNA(IndexToPatternStr(m.Order, start))...)
+	}
+
+	for len(seq) < n {
+		context := PatternToIndex(seq[len(seq)-m.Order:])
+		seq = append(seq, byte(sampleIndex(m.Transitions[context*4:context*4+4], random)))
+	}
+	return seq[:n]
+}
+
+// transition is the probability of base following context.
+func (m *MarkovModel) transition(context sequence, base byte) float64 {
+	return m.Transitions[PatternToIndex(context)*4+int(base)]
+}
+
+// normalize scales values in place to sum to 1.
+func normalize(values []float64) {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	if sum == 0 {
+		return
+	}
+	for i := range values {
+		values[i] = values[i] / sum
+	}
+}
+
+// sampleIndex draws an index with the probabilities in probs.
+func sampleIndex(probs []float64, random *rand.Rand) int {
+	r := random.Float64()
+	for i, p := range probs {
+		r -= p
+		if r < 0 {
+			return i
+		}
+	}
+	return len(probs) - 1
+}
+
+func sumsToOne(values []float64) bool {
+	sum := 0.0
+	for _, v := range values {
+		if v < 0 {
+			return false
+		}
+		sum += v
+	}
+	return math.Abs(sum-1) < 1e-6
+}
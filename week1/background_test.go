@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMarkovModel_iid(t *testing.T) {
+	model := NewMarkovModel(NormalizeDNA("ACGT"), 0)
+
+	assert.Equal(t, []float64{0.25, 0.25, 0.25, 0.25}, model.Transitions)
+	assert.InDelta(t, 1.0/16, model.WordProbability(NormalizeDNA("AC")), 1e-12)
+	assert.InDelta(t, 1.0, model.WordProbability(sequence{}), 1e-12)
+}
+
+func TestNewMarkovModel_order1(t *testing.T) {
+	model := NewMarkovModel(NormalizeDNA("ACACACAC"), 1)
+
+	// after A comes C: (4+1) / (4+4)
+	assert.InDelta(t, 5.0/8, model.transition(NormalizeDNA("A"), 1), 1e-12)
+	assert.InDelta(t, 1.0/8, model.transition(NormalizeDNA("A"), 0), 1e-12)
+	assert.True(t, model.WordProbability(NormalizeDNA("ACA")) > model.WordProbability(NormalizeDNA("AAA")))
+
+	sum := 0.0
+	for i := 0; i < 16; i++ {
+		sum += model.WordProbability(NormalizeDNA(IndexToPatternStr(2, i)))
+	}
+	assert.InDelta(t, 1.0, sum, 1e-12)
+}
+
+func TestTrainMarkovModel(t *testing.T) {
+	model := TrainMarkovModel(NormalizeListDNA([]string{"AAAA", "CCCC"}), 0, 0)
+
+	assert.Equal(t, []float64{0.5, 0.5, 0, 0}, model.Transitions)
+	assert.NoError(t, model.Validate())
+}
+
+func TestMarkovModelLogLikelihood(t *testing.T) {
+	model := NewMarkovModel(NormalizeDNA("ACGTTGCATGTCGCATGATGCATGAGAGCT"), 2)
+
+	for _, word := range []string{"A", "AC", "ACG", "CATGCATG"} {
+		seq := NormalizeDNA(word)
+		assert.InDelta(t, math.Log(model.WordProbability(seq)), model.LogLikelihood(seq), 1e-9, word)
+	}
+
+	long := NormalizeDNA(strings.Repeat("CATG", 500))
+	assert.False(t, math.IsInf(model.LogLikelihood(long), -1))
+}
+
+func TestMarkovModelSample(t *testing.T) {
+	model := NewMarkovModel(NormalizeDNA(strings.Repeat("AC", 1000)), 1)
+
+	seq := model.Sample(1000, rand.New(rand.NewSource(1)))
+	assert.Len(t, seq, 1000)
+
+	// A is almost always followed by C
+	trained := TrainMarkovModel(sequences{seq}, 1, 0)
+	assert.True(t, trained.transition(NormalizeDNA("A"), 1) > 0.95)
+
+	assert.Len(t, NewMarkovModel(NormalizeDNA("ACGT"), 0).Sample(10, rand.New(rand.NewSource(1))), 10)
+	assert.Len(t, model.Sample(0, rand.New(rand.NewSource(1))), 0)
+}
+
+func TestMarkovModelJSON(t *testing.T) {
+	model := NewMarkovModel(NormalizeDNA("ACGTTGCATGTCGCATGATGCATGAGAGCT"), 1)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, model.Write(&buf))
+
+	read, err := ReadMarkovModel(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, model, read)
+
+	_, err = ReadMarkovModel(strings.NewReader(`{"order": 1, "initial": [1], "transitions": [1, 0, 0, 0]}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// canonicalIndex is the lower of the index of a k-mer and the index of its reverse complement.
+func canonicalIndex(k, index int) int {
+	rev := 0
+	for i, rest := 0, index; i < k; i++ {
+		rev = rev<<2 | (3 - rest&3)
+		rest >>= 2
+	}
+	return Min(index, rev)
+}
+
+// CanonicalKmerFrequencies counts the k-mers of normDNA from NewIndex, adding each k-mer to its canonical form,
+// so a contig has the same frequencies whichever strand it was assembled on.
+// The frequencies are in the order of the canonical indexes, and sum to 1.
+func CanonicalKmerFrequencies(normDNA []byte, k int) []float64 {
+	canonical := map[int]int{}
+	order := []int{}
+	for index := 0; index < int(Pow4(k)); index++ {
+		c := canonicalIndex(k, index)
+		if _, ok := canonical[c]; !ok {
+			canonical[c] = len(order)
+			order = append(order, c)
+		}
+	}
+
+	freqs := make([]float64, len(order))
+	for index, count := range NewIndex(normDNA, k).Frequencies() {
+		freqs[canonical[canonicalIndex(k, index)]] += float64(count)
+	}
+	normalize(freqs)
+	return freqs
+}
+
+// BinningMethod is the clustering used by BinContigs.
+type BinningMethod int
+
+const (
+	KMeansBinning BinningMethod = iota
+	HierarchicalBinning
+)
+
+func (m BinningMethod) String() string {
+	if m == HierarchicalBinning {
+		return "hierarchical"
+	}
+	return "kmeans"
+}
+
+// BinningOptions configures BinContigs.
+type BinningOptions struct {
+	Bins   int
+	Method BinningMethod
+	// Iterations and Seed are used by k-means.
+	Iterations int
+	Seed       int64
+	// CoverageWeight scales the log coverage against the tetranucleotide frequencies.
+	// At 1, coverage weighs as much as all the tetranucleotide frequencies together.
+	CoverageWeight float64
+	Filter         ContigFilter
+}
+
+// DefaultBinningOptions bins contigs of at least 1000 bases into 5 bins with k-means.
+func DefaultBinningOptions() BinningOptions {
+	return BinningOptions{
+		Bins:           5,
+		Method:         KMeansBinning,
+		Iterations:     100,
+		Seed:           1,
+		CoverageWeight: 1,
+		Filter:         ContigFilter{MinLength: 1000},
+	}
+}
+
+// Bin is a group of contigs likely to come from the same genome.
+type Bin struct {
+	ID      int
+	Contigs []Fasta
+	Length  int
+	// Coverage is the mean coverage of the contigs, weighted by length.
+	Coverage float64
+}
+
+/*
+   BinContigs(Contigs)
+       for every contig
+           Features ← canonical tetranucleotide frequencies, and the log of the coverage
+       scale every feature to mean 0 and variance 1 over all contigs
+       cluster the contigs by their features
+*/
+
+// BinContigs clusters the contigs passing the filter by tetranucleotide frequency and coverage.
+// Bins are numbered from 1, largest first.
+func BinContigs(records []Fasta, opts BinningOptions) []Bin {
+	contigs := []Fasta{}
+	headers := []ContigHeader{}
+	for i := range records {
+		header := contigHeader(&records[i])
+		if len(records[i].Genome()) > 0 && opts.Filter.Accept(header, len(records[i].Genome())) {
+			contigs = append(contigs, records[i])
+			headers = append(headers, header)
+		}
+	}
+	if len(contigs) == 0 {
+		return []Bin{}
+	}
+
+	assignments := clusterContigs(contigFeatures(contigs, headers, opts.CoverageWeight), opts)
+
+	bins := []Bin{}
+	for i, cluster := range assignments {
+		for len(bins) <= cluster {
+			bins = append(bins, Bin{Contigs: []Fasta{}})
+		}
+		bin := &bins[cluster]
+		bin.Contigs = append(bin.Contigs, contigs[i])
+		bin.Length += len(contigs[i].Genome())
+		bin.Coverage += headers[i].Coverage * float64(len(contigs[i].Genome()))
+	}
+
+	sort.SliceStable(bins, func(i, j int) bool {
+		return bins[i].Length > bins[j].Length
+	})
+	for i := range bins {
+		bins[i].ID = i + 1
+		bins[i].Coverage /= float64(bins[i].Length)
+	}
+	return bins
+}
+
+func clusterContigs(features [][]float64, opts BinningOptions) []int {
+	if opts.Method == HierarchicalBinning {
+		distances := make([][]float64, len(features))
+		for i := range distances {
+			distances[i] = make([]float64, len(features))
+			for j := range features {
+				distances[i][j] = math.Sqrt(SquaredDistance(features[i], features[j]))
+			}
+		}
+		return AverageLinkage(distances).Clusters(opts.Bins)
+	}
+	return KMeans(features, opts.Bins, opts.Iterations, rand.New(rand.NewSource(opts.Seed)))
+}
+
+// contigFeatures are the standardized tetranucleotide frequencies and log coverage of each contig.
+func contigFeatures(contigs []Fasta, headers []ContigHeader, coverageWeight float64) [][]float64 {
+	features := make([][]float64, len(contigs))
+	for i := range contigs {
+		features[i] = append(CanonicalKmerFrequencies(NormalizeDNA(contigs[i].Genome()), 4), math.Log(headers[i].Coverage+1))
+	}
+
+	dimensions := len(features[0])
+	for j := 0; j < dimensions; j++ {
+		mean, variance := 0.0, 0.0
+		for i := range features {
+			mean += features[i][j]
+		}
+		mean /= float64(len(features))
+		for i := range features {
+			variance += (features[i][j] - mean) * (features[i][j] - mean)
+		}
+		sd := math.Sqrt(variance / float64(len(features)))
+
+		// the coverage gets as much weight as the other dimensions together
+		scale := 1.0
+		if j == dimensions-1 {
+			scale = coverageWeight * math.Sqrt(float64(dimensions-1))
+		}
+		for i := range features {
+			if sd == 0 {
+				features[i][j] = 0
+			} else {
+				features[i][j] = scale * (features[i][j] - mean) / sd
+			}
+		}
+	}
+	return features
+}
+
+// WriteBinAssignments writes the bin of every contig as TSV.
+func WriteBinAssignments(w io.Writer, bins []Bin) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "contig\tbin\tlength\tbin_length\tbin_coverage")
+	for _, bin := range bins {
+		for i := range bin.Contigs {
+			fmt.Fprintf(out, "%v\t%d\t%d\t%d\t%.2f\n", bin.Contigs[i].Name(), bin.ID, len(bin.Contigs[i].Genome()), bin.Length, bin.Coverage)
+		}
+	}
+	return out.Flush()
+}
+
+// WriteBinFastas writes the contigs of every bin to dir, as prefix.N.fasta for bin N.
+func WriteBinFastas(dir, prefix string, bins []Bin) error {
+	for _, bin := range bins {
+		file, err := os.Create(filepath.Join(dir, fmt.Sprintf("%v.%d.fasta", prefix, bin.ID)))
+		if err != nil {
+			return err
+		}
+		err = WriteFasta(file, bin.Contigs)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// binCommand bins the contigs of an assembly, writes the assignments as TSV to stdout,
+// and the contigs of every bin to a FASTA file when -out is given.
+//
+//	bin [-n 5] [-hierarchical] [-min-length 1000] [-min-cov 0] [-cov-weight 1] [-seed 1] [-out dir] assembly.fasta
+func binCommand(args []string) error {
+	opts := DefaultBinningOptions()
+	flags := flag.NewFlagSet("bin", flag.ContinueOnError)
+	flags.IntVar(&opts.Bins, "n", opts.Bins, "number of bins")
+	hierarchical := flags.Bool("hierarchical", false, "use average linkage clustering instead of k-means")
+	flags.IntVar(&opts.Filter.MinLength, "min-length", opts.Filter.MinLength, "leave out contigs shorter than this")
+	flags.Float64Var(&opts.Filter.MinCoverage, "min-cov", opts.Filter.MinCoverage, "leave out contigs with lower coverage than this")
+	flags.Float64Var(&opts.CoverageWeight, "cov-weight", opts.CoverageWeight, "weight of coverage against tetranucleotide frequencies")
+	flags.Int64Var(&opts.Seed, "seed", opts.Seed, "random seed for k-means")
+	out := flags.String("out", "", "directory to write a FASTA file per bin to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: bin [flags] assembly.fasta")
+	}
+	if *hierarchical {
+		opts.Method = HierarchicalBinning
+	}
+
+	records, err := ReadFastaRecords(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	bins := BinContigs(records, opts)
+	if *out != "" {
+		if err := WriteBinFastas(*out, sampleName(flags.Arg(0)), bins); err != nil {
+			return err
+		}
+	}
+	return WriteBinAssignments(os.Stdout, bins)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalIndex(t *testing.T) {
+	// ACGT is its own reverse complement
+	assert.Equal(t, PatternToIndexStr("ACGT"), canonicalIndex(4, PatternToIndexStr("ACGT")))
+	assert.Equal(t, PatternToIndexStr("AAAA"), canonicalIndex(4, PatternToIndexStr("TTTT")))
+	assert.Equal(t, PatternToIndexStr("AACG"), canonicalIndex(4, PatternToIndexStr("CGTT")))
+}
+
+func TestCanonicalKmerFrequencies(t *testing.T) {
+	// 136 canonical tetranucleotides
+	freqs := CanonicalKmerFrequencies(NormalizeDNA("AAAAATTTTT"), 4)
+	assert.Len(t, freqs, 136)
+	// AAAA twice and its reverse complement TTTT twice, of the 7 tetranucleotides
+	assert.InDelta(t, 4.0/7, freqs[0], 1e-9)
+
+	// both strands of the same contig have the same frequencies
+	dna := "ACGGTCAGTTAGCCATGACCGTAGGATTACA"
+	assert.Equal(t, CanonicalKmerFrequencies(NormalizeDNA(dna), 4), CanonicalKmerFrequencies(NormalizeDNA(RevComplementStr(dna)), 4))
+}
+
+// randomContigs makes contigs from a genome with the given GC content and coverage.
+func randomContigs(name string, n, length int, gc, coverage float64, random *rand.Rand) []Fasta {
+	contigs := []Fasta{}
+	for i := 0; i < n; i++ {
+		genome := make([]byte, length)
+		for j := range genome {
+			r := random.Float64()
+			switch {
+			case r < gc/2:
+				genome[j] = 'G'
+			case r < gc:
+				genome[j] = 'C'
+			case r < gc+(1-gc)/2:
+				genome[j] = 'A'
+			default:
+				genome[j] = 'T'
+			}
+		}
+		header := fmt.Sprintf(">NODE_%d_length_%d_cov_%g_%v", i+1, length, coverage, name)
+		contigs = append(contigs, Fasta{rawHeader: header, genome: string(genome)})
+	}
+	return contigs
+}
+
+func TestBinContigs(t *testing.T) {
+	random := rand.New(rand.NewSource(7))
+	records := append(randomContigs("low", 4, 2000, 0.3, 10, random), randomContigs("high", 3, 2000, 0.7, 50, random)...)
+	records = append(records, Fasta{rawHeader: ">NODE_9_length_100_cov_10", genome: strings.Repeat("A", 100)})
+
+	for _, method := range []BinningMethod{KMeansBinning, HierarchicalBinning} {
+		opts := DefaultBinningOptions()
+		opts.Bins = 2
+		opts.Method = method
+		bins := BinContigs(records, opts)
+
+		assert.Len(t, bins, 2, method.String())
+		assert.Equal(t, 1, bins[0].ID)
+		assert.Equal(t, 8000, bins[0].Length)
+		assert.InDelta(t, 10, bins[0].Coverage, 1e-9)
+		assert.Len(t, bins[1].Contigs, 3)
+		for i := range bins[1].Contigs {
+			assert.True(t, strings.HasSuffix(bins[1].Contigs[i].Name(), "_high"))
+		}
+	}
+}
+
+func TestWriteBins(t *testing.T) {
+	bins := []Bin{{ID: 1, Contigs: []Fasta{{rawHeader: ">NODE_1", genome: strings.Repeat("ACGT", 20)}}, Length: 80, Coverage: 2}}
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, WriteBinAssignments(&buf, bins))
+	assert.Equal(t, "contig\tbin\tlength\tbin_length\tbin_coverage\nNODE_1\t1\t80\t80\t2.00\n", buf.String())
+
+	dir, err := ioutil.TempDir("", "bins")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, WriteBinFastas(dir, "sample", bins))
+	records, err := ReadFastaRecords(filepath.Join(dir, "sample.1.fasta"))
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "NODE_1", records[0].Name())
+	assert.Equal(t, strings.Repeat("ACGT", 20), records[0].Genome())
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// GenomeMap is a circular map of a genome, with concentric tracks from the outside in:
+// the backbone with a scale, GC content, GC skew in windows, the cumulative skew, clumps and motif hits,
+// with markers at the ori and ter.
+type GenomeMap struct {
+	Title   string
+	Length  int
+	Profile SkewProfile
+	// Skew is the cumulative skew, drawn with at most MaxPoints points.
+	Skew      []int
+	MaxPoints int
+	// Origin and Terminus are the positions of minimum and maximum skew.
+	Origin   []int
+	Terminus []int
+	Clumps   []Clump
+	// Hits are motif hits of length HitLength, forward hits are drawn outside reverse hits.
+	Hits      []Hit
+	HitLength int
+}
+
+// NewGenomeMap creates a map of normDNA, with GC content and skew in windows of windowLength.
+// Clumps and motif hits can be added to the map before writing it.
+func NewGenomeMap(title string, normDNA []byte, windowLength int) *GenomeMap {
+	profile := NewSkewProfile(normDNA, windowLength, windowLength)
+	return &GenomeMap{
+		Title:     title,
+		Length:    len(normDNA),
+		Profile:   profile,
+		Skew:      Skew(normDNA),
+		MaxPoints: 2000,
+		Origin:    profile.Origin,
+		Terminus:  profile.Terminus,
+	}
+}
+
+// the radius of each track, relative to the size of the map
+const (
+	mapBackbone   = 0.88
+	mapGC         = 0.76
+	mapSkew       = 0.62
+	mapCumulative = 0.50
+	mapClumps     = 0.40
+	mapHits       = 0.33
+	mapTrackSize  = 0.06
+)
+
+// WriteSVG draws the map as a size by size SVG image.
+func (m *GenomeMap) WriteSVG(w io.Writer, size int) error {
+	out := bufio.NewWriter(w)
+	svg := &svgMap{out: out, center: float64(size) / 2, scale: float64(size) / 2, length: m.Length}
+
+	fmt.Fprintf(out, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", size, size, size, size)
+	fmt.Fprintf(out, `<rect width="%d" height="%d" fill="white"/>`+"\n", size, size)
+	fmt.Fprintf(out, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%.1f">%v</text>`+"\n",
+		svg.center, svg.center, svg.scale*0.05, html.EscapeString(m.Title))
+	fmt.Fprintf(out, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%.1f">%d bp</text>`+"\n",
+		svg.center, svg.center+svg.scale*0.07, svg.scale*0.035, m.Length)
+
+	if m.Length > 0 {
+		m.writeBackbone(svg)
+		m.writeProfile(svg)
+		m.writeSkew(svg)
+		m.writeClumps(svg)
+		m.writeHits(svg)
+		m.writeMarkers(svg)
+	}
+
+	fmt.Fprintln(out, "</svg>")
+	return out.Flush()
+}
+
+func (m *GenomeMap) writeBackbone(svg *svgMap) {
+	svg.circle(mapBackbone, "black", 2)
+
+	tick := niceTick(m.Length)
+	for pos := 0; pos < m.Length; pos += tick {
+		x1, y1 := svg.point(pos, mapBackbone)
+		x2, y2 := svg.point(pos, mapBackbone+0.03)
+		fmt.Fprintf(svg.out, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="black"/>`+"\n", x1, y1, x2, y2)
+
+		x, y := svg.point(pos, mapBackbone+0.07)
+		fmt.Fprintf(svg.out, `<text x="%.2f" y="%.2f" text-anchor="middle" dominant-baseline="middle" font-family="sans-serif" font-size="%.1f">%v</text>`+"\n",
+			x, y, svg.scale*0.025, formatBases(pos))
+	}
+}
+
+func (m *GenomeMap) writeProfile(svg *svgMap) {
+	windows := m.Profile.Windows
+	if len(windows) == 0 {
+		return
+	}
+
+	meanGC := 0.0
+	maxGCDeviation := 0.0
+	for _, window := range windows {
+		meanGC += window.GC
+	}
+	meanGC /= float64(len(windows))
+	for _, window := range windows {
+		maxGCDeviation = math.Max(maxGCDeviation, math.Abs(window.GC-meanGC))
+	}
+
+	svg.circle(mapGC, "lightgray", 1)
+	svg.circle(mapSkew, "lightgray", 1)
+	for _, window := range windows {
+		if maxGCDeviation > 0 {
+			height := mapTrackSize * (window.GC - meanGC) / maxGCDeviation
+			svg.sector(window.Start, window.End, mapGC, mapGC+height, "black")
+		}
+
+		color := "green"
+		if window.GCSkew < 0 {
+			color = "purple"
+		}
+		svg.sector(window.Start, window.End, mapSkew, mapSkew+mapTrackSize*window.GCSkew, color)
+	}
+}
+
+func (m *GenomeMap) writeSkew(svg *svgMap) {
+	if len(m.Skew) == 0 {
+		return
+	}
+	_, min := Minimum(m.Skew)
+	scale := float64(Max(-min, Maximum(m.Skew)))
+	if scale == 0 {
+		scale = 1
+	}
+
+	svg.circle(mapCumulative, "lightgray", 1)
+	fmt.Fprint(svg.out, `<polyline fill="none" stroke="black" stroke-width="1" points="`)
+	for i, pt := range downsamplePlotPoints(m.Skew, m.MaxPoints) {
+		if i > 0 {
+			fmt.Fprint(svg.out, " ")
+		}
+		x, y := svg.pointAt(pt.X, mapCumulative+mapTrackSize*pt.Y/scale)
+		fmt.Fprintf(svg.out, "%.2f,%.2f", x, y)
+	}
+	fmt.Fprintln(svg.out, `"/>`)
+}
+
+func (m *GenomeMap) writeClumps(svg *svgMap) {
+	for _, clump := range m.Clumps {
+		end := clump.End
+		if end <= clump.Start {
+			// the clump crosses the origin
+			end += m.Length
+		}
+		svg.sector(clump.Start, end, mapClumps-mapTrackSize/2, mapClumps+mapTrackSize/2, "orange")
+	}
+}
+
+func (m *GenomeMap) writeHits(svg *svgMap) {
+	length := Max(m.HitLength, 1)
+	for _, hit := range m.Hits {
+		inner, color := mapHits, "red"
+		if hit.Strand == Reverse {
+			inner, color = mapHits-mapTrackSize/2, "blue"
+		}
+		svg.sector(hit.Pos, hit.Pos+length, inner, inner+mapTrackSize/2, color)
+	}
+}
+
+func (m *GenomeMap) writeMarkers(svg *svgMap) {
+	for _, marker := range []struct {
+		name      string
+		positions []int
+		color     string
+	}{
+		{"ori", m.Origin, "green"},
+		{"ter", m.Terminus, "purple"},
+	} {
+		if len(marker.positions) == 0 {
+			continue
+		}
+		// tied positions are usually next to each other, mark the first
+		pos := marker.positions[0]
+		x1, y1 := svg.point(pos, mapHits-mapTrackSize)
+		x2, y2 := svg.point(pos, mapBackbone)
+		fmt.Fprintf(svg.out, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%v" stroke-width="2" stroke-dasharray="4 2"/>`+"\n",
+			x1, y1, x2, y2, marker.color)
+
+		x, y := svg.point(pos, mapHits-mapTrackSize-0.05)
+		fmt.Fprintf(svg.out, `<text x="%.2f" y="%.2f" text-anchor="middle" dominant-baseline="middle" font-family="sans-serif" font-size="%.1f" fill="%v">%v</text>`+"\n",
+			x, y, svg.scale*0.03, marker.color, marker.name)
+	}
+}
+
+// svgMap draws on a circle, where position 0 is at the top and positions increase clockwise.
+type svgMap struct {
+	out    io.Writer
+	center float64
+	scale  float64
+	length int
+}
+
+// point is the position pos at radius, where radius is relative to the size of the map.
+func (s *svgMap) point(pos int, radius float64) (float64, float64) {
+	return s.pointAt(float64(pos), radius)
+}
+
+func (s *svgMap) pointAt(pos, radius float64) (float64, float64) {
+	angle := 2*math.Pi*pos/float64(s.length) - math.Pi/2
+	return s.center + radius*s.scale*math.Cos(angle), s.center + radius*s.scale*math.Sin(angle)
+}
+
+func (s *svgMap) circle(radius float64, color string, width float64) {
+	fmt.Fprintf(s.out, `<circle cx="%.2f" cy="%.2f" r="%.2f" fill="none" stroke="%v" stroke-width="%.1f"/>`+"\n",
+		s.center, s.center, radius*s.scale, color, width)
+}
+
+// sector draws the ring between radius r1 and r2 from position start to end.
+func (s *svgMap) sector(start, end int, r1, r2 float64, color string) {
+	if r1 > r2 {
+		r1, r2 = r2, r1
+	}
+	if end-start >= s.length {
+		// a full ring can't be drawn as one arc
+		half := start + s.length/2
+		s.sector(start, half, r1, r2, color)
+		s.sector(half, start+s.length, r1, r2, color)
+		return
+	}
+
+	largeArc := 0
+	if float64(end-start) > float64(s.length)/2 {
+		largeArc = 1
+	}
+	ox1, oy1 := s.point(start, r2)
+	ox2, oy2 := s.point(end, r2)
+	ix2, iy2 := s.point(end, r1)
+	ix1, iy1 := s.point(start, r1)
+	fmt.Fprintf(s.out, `<path d="M %.2f %.2f A %.2f %.2f 0 %d 1 %.2f %.2f L %.2f %.2f A %.2f %.2f 0 %d 0 %.2f %.2f Z" fill="%v"/>`+"\n",
+		ox1, oy1, r2*s.scale, r2*s.scale, largeArc, ox2, oy2,
+		ix2, iy2, r1*s.scale, r1*s.scale, largeArc, ix1, iy1,
+		color)
+}
+
+// niceTick picks a round distance between ticks, giving about 10 ticks around the genome.
+func niceTick(length int) int {
+	tick := 1
+	for tick*10 < length {
+		switch {
+		case tick*2*10 >= length:
+			return tick * 2
+		case tick*5*10 >= length:
+			return tick * 5
+		}
+		tick *= 10
+	}
+	return tick
+}
+
+func formatBases(pos int) string {
+	switch {
+	case pos >= 1000000:
+		return fmt.Sprintf("%g Mb", float64(pos)/1000000)
+	case pos >= 1000:
+		return fmt.Sprintf("%g kb", float64(pos)/1000)
+	}
+	return fmt.Sprintf("%d", pos)
+}
+
+// mapCommand draws a circular map of a genome file, and writes the SVG to stdout.
+//
+//	map [-window 5000] [-size 1000] [-k 9 -L 500 -t 3] [-motif TTATCCACA] [-d 1] genome.fasta
+func mapCommand(args []string) error {
+	flags := flag.NewFlagSet("map", flag.ContinueOnError)
+	windowLength := flags.Int("window", 5000, "length of the GC content and skew windows")
+	size := flags.Int("size", 1000, "width and height of the image")
+	k := flags.Int("k", 9, "length of the clumped k-mers")
+	clumpLength := flags.Int("L", 500, "length of the clump windows")
+	times := flags.Int("t", 0, "occurrences of a k-mer in a window making a clump, 0 to leave out clumps")
+	motifs := flags.String("motif", "", "comma separated motifs to mark on both strands")
+	mismatches := flags.Int("d", 0, "mismatches allowed in motif hits")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: map [flags] genome.fasta")
+	}
+
+	genome, err := readGenome(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	genome = strings.ToUpper(genome)
+	normDNA := NormalizeDNA(genome)
+
+	genomeMap := NewGenomeMap(flags.Arg(0), normDNA, *windowLength)
+	if *times > 0 {
+		genomeMap.Clumps = FindClumps(normDNA, *k, *clumpLength, *times, Circular)
+	}
+	if *motifs != "" {
+		for _, motif := range strings.Split(*motifs, ",") {
+			motif = strings.ToUpper(motif)
+			genomeMap.Hits = append(genomeMap.Hits, ApproximateSubStringHits(genome, motif, *mismatches, true)...)
+			genomeMap.HitLength = Max(genomeMap.HitLength, len(motif))
+		}
+		sortHits(genomeMap.Hits)
+	}
+
+	return genomeMap.WriteSVG(os.Stdout, *size)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// svgElements counts the elements of svg by name, failing if it is not well formed XML.
+func svgElements(t *testing.T, svg string) map[string]int {
+	elements := map[string]int{}
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return elements
+		}
+		if !assert.NoError(t, err) {
+			return elements
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			elements[start.Name.Local]++
+		}
+	}
+}
+
+func TestGenomeMapWriteSVG(t *testing.T) {
+	genome := strings.Repeat("CCCCATTTGCAA", 50) + strings.Repeat("GGGGGTTTGCAT", 50)
+	genomeMap := NewGenomeMap("test <genome>", NormalizeDNA(genome), 120)
+	genomeMap.Clumps = []Clump{{Pattern: "TTTGC", Start: 1100, End: 100}}
+	genomeMap.Hits = SubStringHits(genome, "TTTGC", true)
+	genomeMap.HitLength = 5
+
+	buf := bytes.Buffer{}
+	err := genomeMap.WriteSVG(&buf, 500)
+	assert.NoError(t, err)
+
+	svg := buf.String()
+	elements := svgElements(t, svg)
+	assert.Equal(t, 1, elements["svg"])
+	assert.Equal(t, 1, elements["polyline"])
+	// GC content and GC skew for each window, a clump across the origin, and the hits
+	windows := len(genomeMap.Profile.Windows)
+	assert.Equal(t, 10, windows)
+	assert.Equal(t, 2*windows+1+len(genomeMap.Hits), elements["path"])
+	assert.Contains(t, svg, "test &lt;genome&gt;")
+	assert.Contains(t, svg, ">ori</text>")
+	assert.Contains(t, svg, ">ter</text>")
+}
+
+func TestGenomeMapWriteSVGEmpty(t *testing.T) {
+	genomeMap := NewGenomeMap("empty", []byte{}, 100)
+
+	buf := bytes.Buffer{}
+	err := genomeMap.WriteSVG(&buf, 100)
+	assert.NoError(t, err)
+
+	elements := svgElements(t, buf.String())
+	assert.Equal(t, 1, elements["svg"])
+	assert.Equal(t, 0, elements["path"])
+}
+
+func TestSvgMapSector(t *testing.T) {
+	buf := bytes.Buffer{}
+	svg := &svgMap{out: &buf, center: 100, scale: 100, length: 100}
+
+	// a quarter from the top to the right
+	svg.sector(0, 25, 0.5, 1, "red")
+	assert.Equal(t, `<path d="M 100.00 0.00 A 100.00 100.00 0 0 1 200.00 100.00 L 150.00 100.00 A 50.00 50.00 0 0 0 100.00 50.00 Z" fill="red"/>`+"\n", buf.String())
+
+	// the whole circle is drawn as two halves
+	buf.Reset()
+	svg.sector(0, 100, 0.5, 1, "red")
+	assert.Equal(t, 2, strings.Count(buf.String(), "<path"))
+}
+
+func TestNiceTick(t *testing.T) {
+	assert.Equal(t, 1, niceTick(5))
+	assert.Equal(t, 200, niceTick(1200))
+	assert.Equal(t, 500000, niceTick(4641652))
+	assert.Equal(t, "0", formatBases(0))
+	assert.Equal(t, "1.5 kb", formatBases(1500))
+	assert.Equal(t, "4.5 Mb", formatBases(4500000))
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Clump is a region of the genome where Pattern occurs at least t times in every window of length L.
+// Overlapping windows are merged, so [Start, End) is the maximal interval covered by such windows.
+// On a circular genome an interval crossing the origin has End <= Start.
+type Clump struct {
+	Pattern     string
+	Start       int
+	End         int
+	Occurrences []int
+}
+
+func (c Clump) String() string {
+	return fmt.Sprintf("%v [%d, %d) %v", c.Pattern, c.Start, c.End, c.Occurrences)
+}
+
+/*
+   FindClumps slides a window of length L over the genome, keeping the positions of each k-mer
+   inside the window. A clump opens the first time a k-mer reaches t occurrences in a window,
+   and closes in the first window where it has fewer than t occurrences. A closed clump is only
+   reported once the window has moved past its end, as a later window overlapping it extends it.
+*/
+
+// FindClumpsStr finds (L, t)-clumps of k-mers in dna and reports where they are.
+func FindClumpsStr(dna string, k, windowLength, times int, topology Topology) []Clump {
+	return FindClumps(NormalizeDNA(dna), k, windowLength, times, topology)
+}
+
+// FindClumps finds (L, t)-clumps of k-mers in normalized DNA and reports where they are.
+// Clumps are sorted by start position, then by pattern.
+func FindClumps(normDNA []byte, k, windowLength, times int, topology Topology) []Clump {
+	dnaLen := len(normDNA)
+	if k > windowLength || windowLength > dnaLen {
+		return []Clump{}
+	}
+
+	numWindows := dnaLen - windowLength + 1
+	if topology == Circular {
+		numWindows = dnaLen
+	}
+	dna := topology.extend(normDNA, windowLength-1)
+
+	clumps := []Clump{}
+	finder := newClumpFinder(k, windowLength, times, func(clump Clump) {
+		clumps = append(clumps, clump)
+	})
+
+	for p := 0; p <= windowLength-k; p++ {
+		finder.push(PatternToIndex(dna[p:p+k]), p)
+	}
+	finder.openAll(0)
+
+	for i := 1; i < numWindows; i++ {
+		// remove the k-mer we are moving out of
+		firstIdx := PatternToIndex(dna[i-1 : i-1+k])
+		finder.pop(firstIdx)
+
+		// add the k-mer we are moving into
+		lastPos := i + windowLength - k
+		lastIdx := PatternToIndex(dna[lastPos : lastPos+k])
+		finder.push(lastIdx, lastPos)
+
+		finder.update(firstIdx, i)
+		finder.update(lastIdx, i)
+	}
+	finder.flush(numWindows - 1)
+
+	if topology == Circular {
+		clumps = joinCircularClumps(clumps, dnaLen, numWindows-1+windowLength)
+	}
+
+	sortClumps(clumps)
+	return clumps
+}
+
+/*
+   ClumpsWithMismatches counts, for every pattern, the k-mers in the window with at most d mismatches
+   to it. Each k-mer adds one to every pattern in its d-neighborhood, so moving the window only needs
+   the neighborhoods of the k-mer moving out and the k-mer moving in.
+*/
+
+// ClumpsWithMismatches finds patterns occurring at least t times with at most d mismatches in a window of length L.
+// With bothStrands, approximate occurrences of the reverse complement count as well, and are given as RevCount.
+// A palindrome is its own reverse complement, so like ApproximateSubStringHits it is only counted once, in Count.
+// Count and RevCount are the counts in the window where the pattern was most frequent.
+func ClumpsWithMismatches(genome string, k, windowLength, times, d int, bothStrands bool) map[string]FreqWordResult {
+	normDNA := NormalizeDNA(genome)
+	dnaLen := len(normDNA)
+
+	wanted := map[string]FreqWordResult{}
+	if k > windowLength || windowLength > dnaLen {
+		return wanted
+	}
+
+	counts := createKmerArray(k)
+	revCounts := createKmerArray(k)
+	best := map[int]FreqWordResult{}
+
+	// record keeps the counts of idx if this window is the best seen so far
+	record := func(idx int) {
+		total := counts[idx] + revCounts[idx]
+		if total < times {
+			return
+		}
+		stored, ok := best[idx]
+		if !ok || total > stored.Count+stored.RevCount {
+			best[idx] = FreqWordResult{Count: counts[idx], RevCount: revCounts[idx]}
+		}
+	}
+	// update adds delta to every pattern within distance d of kmer, and records the counts when adding
+	update := func(kmer sequence, delta int) {
+		for _, neighbor := range NeighborsSimple(kmer, d) {
+			idx := PatternToIndex(neighbor)
+			counts[idx] += delta
+			if delta > 0 {
+				record(idx)
+			}
+		}
+		if !bothStrands {
+			return
+		}
+		for _, neighbor := range NeighborsSimple(RevComplement(kmer), d) {
+			idx := PatternToIndex(neighbor)
+			if PatternToIndex(RevComplement(neighbor)) == idx {
+				// a palindrome reads the same on both strands, so it was counted above
+				continue
+			}
+			revCounts[idx] += delta
+			if delta > 0 {
+				record(idx)
+			}
+		}
+	}
+
+	for i := 0; i <= windowLength-k; i++ {
+		update(normDNA[i:i+k], 1)
+	}
+
+	for i := 1; i <= dnaLen-windowLength; i++ {
+		// remove the k-mer we are moving out of
+		update(normDNA[i-1:i-1+k], -1)
+
+		// add the k-mer we are moving into
+		last := i + windowLength - k
+		update(normDNA[last:last+k], 1)
+	}
+
+	for idx, result := range best {
+		pattern := IndexToPatternStr(k, idx)
+		result.Pattern = pattern
+		if bothStrands {
+			result.RevPattern = RevComplementStr(pattern)
+		}
+		wanted[pattern] = result
+	}
+
+	return wanted
+}
+
+// closedClump is the k-mer and end of a clump when it closed.
+type closedClump struct {
+	idx int
+	end int
+}
+
+type clumpFinder struct {
+	k            int
+	windowLength int
+	times        int
+	// positions of every k-mer in the current window, oldest first
+	window map[int][]int
+	open   map[int]*Clump
+	// closed clumps a later window may still overlap, and the order they closed in
+	pending map[int]*Clump
+	closed  []closedClump
+	emit    func(Clump)
+}
+
+func newClumpFinder(k, windowLength, times int, emit func(Clump)) *clumpFinder {
+	return &clumpFinder{
+		k:            k,
+		windowLength: windowLength,
+		times:        times,
+		window:       map[int][]int{},
+		open:         map[int]*Clump{},
+		pending:      map[int]*Clump{},
+		emit:         emit,
+	}
+}
+
+// push adds the k-mer idx found at pos to the window.
+func (c *clumpFinder) push(idx, pos int) {
+	c.window[idx] = append(c.window[idx], pos)
+	if clump, ok := c.open[idx]; ok {
+		clump.Occurrences = append(clump.Occurrences, pos)
+	}
+}
+
+// pop removes the oldest occurrence of the k-mer idx from the window.
+func (c *clumpFinder) pop(idx int) {
+	positions := c.window[idx]
+	if len(positions) <= 1 {
+		delete(c.window, idx)
+		return
+	}
+	c.window[idx] = positions[1:]
+}
+
+// openAll opens a clump for every k-mer that forms a clump in the window starting at windowStart.
+func (c *clumpFinder) openAll(windowStart int) {
+	for idx := range c.window {
+		c.update(idx, windowStart)
+	}
+}
+
+// update opens or closes the clump of the k-mer idx for the window starting at windowStart.
+// A clump opening in a window overlapping the clump of idx that closed last extends that clump.
+func (c *clumpFinder) update(idx, windowStart int) {
+	c.expire(windowStart)
+	clump, isOpen := c.open[idx]
+	isClump := len(c.window[idx]) >= c.times
+
+	if isOpen && !isClump {
+		clump.End = windowStart - 1 + c.windowLength
+		delete(c.open, idx)
+		c.pending[idx] = clump
+		c.closed = append(c.closed, closedClump{idx, clump.End})
+	} else if !isOpen && isClump {
+		if clump, ok := c.pending[idx]; ok {
+			last := clump.Occurrences[len(clump.Occurrences)-1]
+			for _, pos := range c.window[idx] {
+				if pos > last {
+					clump.Occurrences = append(clump.Occurrences, pos)
+				}
+			}
+			delete(c.pending, idx)
+			c.open[idx] = clump
+			return
+		}
+
+		positions := c.window[idx]
+		occurrences := make([]int, len(positions))
+		copy(occurrences, positions)
+
+		c.open[idx] = &Clump{
+			Pattern:     IndexToPatternStr(c.k, idx),
+			Start:       windowStart,
+			Occurrences: occurrences,
+		}
+	}
+}
+
+// expire reports the closed clumps ending at or before windowStart, as no window from there on overlaps them.
+// Clumps close in the order of their ends, so these are at the front of closed.
+func (c *clumpFinder) expire(windowStart int) {
+	for len(c.closed) > 0 && c.closed[0].end <= windowStart {
+		closed := c.closed[0]
+		c.closed = c.closed[1:]
+		// a clump reopened since, and maybe closed again with a later end, is not done
+		if clump, ok := c.pending[closed.idx]; ok && clump.End == closed.end {
+			delete(c.pending, closed.idx)
+			c.emit(*clump)
+		}
+	}
+}
+
+// flush reports the closed clumps, and closes all clumps still open in the last window.
+func (c *clumpFinder) flush(lastWindowStart int) {
+	c.expire(lastWindowStart + c.windowLength)
+
+	open := make([]int, 0, len(c.open))
+	for idx := range c.open {
+		open = append(open, idx)
+	}
+	sort.Ints(open)
+
+	for _, idx := range open {
+		clump := c.open[idx]
+		clump.End = lastWindowStart + c.windowLength
+		delete(c.open, idx)
+		c.emit(*clump)
+	}
+}
+
+// joinCircularClumps joins the clumps open in both the first and last window, since they are the same clump
+// crossing the origin, and wraps all coordinates onto a genome of length dnaLen.
+func joinCircularClumps(clumps []Clump, dnaLen, lastEnd int) []Clump {
+	first := map[string]int{}
+	last := map[string]int{}
+	for i := range clumps {
+		if clumps[i].Start == 0 {
+			first[clumps[i].Pattern] = i
+		}
+		if clumps[i].End == lastEnd {
+			last[clumps[i].Pattern] = i
+		}
+	}
+
+	joined := make([]Clump, 0, len(clumps))
+	for i, clump := range clumps {
+		if j, ok := last[clump.Pattern]; ok && clump.Start == 0 && j != i {
+			// the head of a clump crossing the origin, it is joined with its tail below
+			continue
+		}
+
+		if j, ok := first[clump.Pattern]; ok && clump.End == lastEnd {
+			if j != i {
+				clump.End = clumps[j].End + dnaLen
+				clump.Occurrences = append(clump.Occurrences, clumps[j].Occurrences...)
+			}
+			if clump.End-clump.Start >= dnaLen {
+				clump.Start = 0
+				clump.End = dnaLen
+			}
+		}
+
+		joined = append(joined, wrapClump(clump, dnaLen))
+	}
+	return joined
+}
+
+func wrapClump(clump Clump, dnaLen int) Clump {
+	start := Circular.wrap(clump.Start, dnaLen)
+	end := Circular.wrap(clump.End-1, dnaLen) + 1
+
+	seen := map[int]bool{}
+	occurrences := make([]int, 0, len(clump.Occurrences))
+	for _, pos := range clump.Occurrences {
+		pos = Circular.wrap(pos, dnaLen)
+		if !seen[pos] {
+			seen[pos] = true
+			occurrences = append(occurrences, pos)
+		}
+	}
+	// order the occurrences as they are met walking from the start of the clump
+	sort.Slice(occurrences, func(i, j int) bool {
+		return (occurrences[i]-start+dnaLen)%dnaLen < (occurrences[j]-start+dnaLen)%dnaLen
+	})
+
+	return Clump{
+		Pattern:     clump.Pattern,
+		Start:       start,
+		End:         end,
+		Occurrences: occurrences,
+	}
+}
+
+func sortClumps(clumps []Clump) {
+	sort.Slice(clumps, func(i, j int) bool {
+		if clumps[i].Start != clumps[j].Start {
+			return clumps[i].Start < clumps[j].Start
+		}
+		return clumps[i].Pattern < clumps[j].Pattern
+	})
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"io/ioutil"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindClumps(t *testing.T) {
+	dna := "CGGACTCGACAGATGTGAAGAACGACAATGTGAAGACTCGACACGACAGAGTGAAGAGAAGAGGAAACATTGTAA"
+
+	clumps := FindClumpsStr(dna, 5, 50, 4, Linear)
+
+	patterns := []string{}
+	for _, clump := range clumps {
+		patterns = append(patterns, clump.Pattern)
+		assert.True(t, len(clump.Occurrences) >= 4)
+	}
+	sort.Strings(patterns)
+	assert.Equal(t, []string{"CGACA", "GAAGA"}, patterns)
+}
+
+func TestFindClumps_lastWindow(t *testing.T) {
+	assert.Equal(t,
+		[]Clump{
+			{Pattern: "CC", Start: 0, End: 4, Occurrences: []int{0, 1, 2}},
+			{Pattern: "AA", Start: 4, End: 8, Occurrences: []int{4, 5, 6}},
+		},
+		FindClumpsStr("CCCCAAAA", 2, 4, 3, Linear))
+
+	assert.Equal(t, []Clump{}, FindClumpsStr("CCC", 2, 4, 1, Linear))
+}
+
+func TestFindClumps_reopened(t *testing.T) {
+	// A drops below 2 occurrences in CAC, and comes back in ACA overlapping its clump
+	assert.Equal(t,
+		[]Clump{
+			{Pattern: "A", Start: 0, End: 5, Occurrences: []int{0, 2, 4}},
+			{Pattern: "C", Start: 1, End: 4, Occurrences: []int{1, 3}},
+		},
+		FindClumpsStr("ACACA", 1, 3, 2, Linear))
+
+	// clumps that only touch stay apart
+	assert.Equal(t,
+		[]Clump{
+			{Pattern: "A", Start: 0, End: 3, Occurrences: []int{0, 1}},
+			{Pattern: "C", Start: 1, End: 5, Occurrences: []int{2, 3}},
+			{Pattern: "A", Start: 3, End: 6, Occurrences: []int{4, 5}},
+		},
+		FindClumpsStr("AACCAA", 1, 3, 2, Linear))
+}
+
+func TestFindClumps_circular(t *testing.T) {
+	assert.Equal(t,
+		[]Clump{{Pattern: "CC", Start: 2, End: 6, Occurrences: []int{2, 3, 4}}},
+		FindClumpsStr("AACCCCAA", 2, 4, 3, Linear))
+
+	assert.Equal(t,
+		[]Clump{
+			{Pattern: "CC", Start: 2, End: 6, Occurrences: []int{2, 3, 4}},
+			{Pattern: "AA", Start: 6, End: 2, Occurrences: []int{6, 7, 0}},
+		},
+		FindClumpsStr("AACCCCAA", 2, 4, 3, Circular))
+
+	// the clump of AA at the start is joined with the one at the end
+	assert.Equal(t,
+		[]Clump{
+			{Pattern: "GG", Start: 2, End: 8, Occurrences: []int{3, 4, 5}},
+			{Pattern: "AA", Start: 6, End: 4, Occurrences: []int{7, 0, 1}},
+		},
+		FindClumpsStr("AAAGGGGA", 2, 4, 2, Circular))
+
+	assert.Equal(t,
+		[]Clump{{Pattern: "AA", Start: 0, End: 4, Occurrences: []int{0, 1, 2, 3}}},
+		FindClumpsStr("AAAA", 2, 3, 2, Circular))
+}
+
+func TestFindClumps_bigger(t *testing.T) {
+	data, err := ioutil.ReadFile("dataset_4_5.txt")
+	if err != nil {
+		t.Errorf("error reading test data: %v", err)
+	}
+
+	clumps := FindClumpsStr(string(data), 10, 481, 18, Linear)
+	results := MovingWindowFrequentWordsFaster(string(data), 10, 481, 18)
+
+	patterns := map[string]bool{}
+	for _, clump := range clumps {
+		patterns[clump.Pattern] = true
+		assert.Contains(t, results, clump.Pattern)
+	}
+	assert.Len(t, patterns, len(results))
+}
+
+func TestClumpsWithMismatches(t *testing.T) {
+	results := ClumpsWithMismatches("AATCAAGCAAC", 3, 11, 5, 1, false)
+
+	assert.Contains(t, results, "AAA")
+	assert.Equal(t, 5, results["AAA"].Count)
+	assert.Equal(t, 0, results["AAA"].RevCount)
+}
+
+func TestClumpsWithMismatches_exact(t *testing.T) {
+	dna := "CGGACTCGACAGATGTGAAGAACGACAATGTGAAGACTCGACACGACAGAGTGAAGAGAAGAGGAAACATTGTAA"
+
+	results := ClumpsWithMismatches(dna, 5, 50, 4, 0, false)
+	expected := MovingWindowFrequentWordsFaster(dna, 5, 50, 4)
+
+	assert.Len(t, results, len(expected))
+	for pattern, result := range expected {
+		assert.Equal(t, result.Count, results[pattern].Count)
+	}
+}
+
+func TestClumpsWithMismatches_bothStrands(t *testing.T) {
+	results := ClumpsWithMismatches("TTTT", 3, 4, 2, 0, true)
+
+	assert.Equal(t, FreqWordResult{Pattern: "AAA", RevPattern: "TTT", Count: 0, RevCount: 2}, results["AAA"])
+	assert.Equal(t, FreqWordResult{Pattern: "TTT", RevPattern: "AAA", Count: 2, RevCount: 0}, results["TTT"])
+	assert.Len(t, results, 2)
+}
+
+func TestClumpsWithMismatches_palindrome(t *testing.T) {
+	dna := "ACGTTTACGTTTACGT"
+	results := ClumpsWithMismatches(dna, 4, len(dna), 3, 0, true)
+
+	assert.Equal(t, FreqWordResult{Pattern: "ACGT", RevPattern: "ACGT", Count: 3, RevCount: 0}, results["ACGT"])
+	assert.Equal(t, results["ACGT"].Count, len(ApproximateSubStringHits(dna, "ACGT", 0, true)))
+	assert.Len(t, results, 1)
+}
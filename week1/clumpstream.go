@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ClumpStream finds (L, t)-clumps in FASTA records read from a stream.
+// Only the k-mers of the current window are kept, in a ring buffer of L-k+1 rolling k-mer codes,
+// so memory does not grow with the length of the input. Each record is searched on its own as a linear sequence.
+type ClumpStream struct {
+	k            int
+	windowLength int
+	times        int
+	emit         func(record string, clump Clump)
+
+	record string
+	finder *clumpFinder
+	ring   []int
+	kmer   int
+	mask   int
+	// number of bases read in the current record, and since the last base that is not A, C, G or T
+	bases int
+	valid int
+}
+
+// noKmer marks a slot of the ring whose k-mer spans a base that is not A, C, G or T.
+const noKmer = -1
+
+// NewClumpStream creates a ClumpStream calling emit for every clump as soon as no later window can extend it.
+// Positions in the clumps are relative to the start of their record.
+// Bases added before the first record header are read as a record with an empty name.
+func NewClumpStream(k, windowLength, times int, emit func(record string, clump Clump)) (*ClumpStream, error) {
+	if k < 1 || k > windowLength {
+		return nil, errors.New("k must be between 1 and the window length")
+	}
+	s := &ClumpStream{
+		k:            k,
+		windowLength: windowLength,
+		times:        times,
+		emit:         emit,
+		ring:         make([]int, windowLength-k+1),
+		mask:         int(Pow4(k)) - 1,
+	}
+	s.startRecord("")
+	return s, nil
+}
+
+// StreamClumps reads FASTA records from r and calls emit for every clump as soon as no later window can extend it.
+// Input without a header line is read as a single record with an empty name.
+func StreamClumps(r io.Reader, k, windowLength, times int, emit func(record string, clump Clump)) error {
+	s, err := NewClumpStream(k, windowLength, times, emit)
+	if err != nil {
+		return err
+	}
+	return s.Scan(r)
+}
+
+// Scan reads FASTA records from r until EOF.
+// The record of any bases added before with AddBase ends first, so its clumps are reported.
+func (s *ClumpStream) Scan(r io.Reader) error {
+	reader := bufio.NewReader(r)
+	s.endRecord()
+	s.startRecord("")
+
+	lineStart := true
+	for {
+		b, err := reader.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if lineStart && b == '>' {
+			header, err := reader.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return err
+			}
+			s.endRecord()
+			s.startRecord(strings.TrimSpace(header))
+			continue
+		}
+
+		lineStart = b == '\n'
+		s.AddBase(b)
+	}
+
+	s.endRecord()
+	s.startRecord("")
+	return nil
+}
+
+// AddBase adds the next base of the current record. Anything not a letter, like newlines, is skipped.
+// Other letters, like the N of assembly gaps, take a position but are in no k-mer.
+func (s *ClumpStream) AddBase(b byte) {
+	if b >= 'a' && b <= 'z' {
+		b = b - 'a' + 'A'
+	}
+	if b < 'A' || b > 'Z' {
+		return
+	}
+
+	switch b {
+	case 'A', 'C', 'G', 'T':
+		s.kmer = (s.kmer<<2 | int(patToIndex[b])) & s.mask
+		s.valid++
+	default:
+		s.kmer = 0
+		s.valid = 0
+	}
+	s.bases++
+
+	start := s.bases - s.k
+	if start < 0 {
+		return
+	}
+	kmer := s.kmer
+	if s.valid < s.k {
+		kmer = noKmer
+	}
+
+	// the window ends with the k-mer starting at start
+	kmersInWindow := len(s.ring)
+	slot := start % kmersInWindow
+	if start < kmersInWindow {
+		s.ring[slot] = kmer
+		if kmer != noKmer {
+			s.finder.push(kmer, start)
+		}
+		if start == kmersInWindow-1 {
+			s.finder.openAll(0)
+		}
+		return
+	}
+
+	windowStart := start - kmersInWindow + 1
+
+	// the k-mer we are moving out of has the slot we are moving into
+	first := s.ring[slot]
+	if first != noKmer {
+		s.finder.pop(first)
+	}
+
+	s.ring[slot] = kmer
+	if kmer != noKmer {
+		s.finder.push(kmer, start)
+	}
+
+	if first != noKmer {
+		s.finder.update(first, windowStart)
+	}
+	if kmer != noKmer {
+		s.finder.update(kmer, windowStart)
+	}
+}
+
+func (s *ClumpStream) startRecord(name string) {
+	s.record = name
+	s.kmer = 0
+	s.bases = 0
+	s.valid = 0
+	s.finder = newClumpFinder(s.k, s.windowLength, s.times, func(clump Clump) {
+		s.emit(s.record, clump)
+	})
+}
+
+// endRecord closes the clumps still open at the end of the current record.
+func (s *ClumpStream) endRecord() {
+	if s.bases >= s.windowLength {
+		s.finder.flush(s.bases - s.windowLength)
+	}
+}
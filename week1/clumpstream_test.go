@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamClumps(t *testing.T) {
+	fasta := ">a first\nCCCC\nAAAA\n>b\nGGGT\n"
+
+	records := []string{}
+	clumps := []Clump{}
+	err := StreamClumps(strings.NewReader(fasta), 2, 4, 2, func(record string, clump Clump) {
+		records = append(records, record)
+		clumps = append(clumps, clump)
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"a first", "a first", "b"}, records)
+	assert.Equal(t, []Clump{
+		{Pattern: "CC", Start: 0, End: 5, Occurrences: []int{0, 1, 2}},
+		{Pattern: "AA", Start: 3, End: 8, Occurrences: []int{4, 5, 6}},
+		{Pattern: "GG", Start: 0, End: 4, Occurrences: []int{0, 1}},
+	}, clumps)
+}
+
+func TestStreamClumps_sameAsFindClumps(t *testing.T) {
+	file, err := os.Open("dataset_4_5.txt")
+	assert.NoError(t, err)
+	defer file.Close()
+
+	clumps := []Clump{}
+	err = StreamClumps(file, 10, 481, 18, func(record string, clump Clump) {
+		clumps = append(clumps, clump)
+	})
+	assert.NoError(t, err)
+	sortClumps(clumps)
+
+	data, err := os.ReadFile("dataset_4_5.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, FindClumpsStr(strings.TrimSpace(string(data)), 10, 481, 18, Linear), clumps)
+}
+
+func TestStreamClumps_windowTooSmall(t *testing.T) {
+	err := StreamClumps(strings.NewReader("ACGT"), 5, 4, 2, func(record string, clump Clump) {})
+	assert.Error(t, err)
+}
+
+func TestNewClumpStream(t *testing.T) {
+	_, err := NewClumpStream(5, 4, 2, func(record string, clump Clump) {})
+	assert.EqualError(t, err, "k must be between 1 and the window length")
+
+	clumps := []Clump{}
+	s, err := NewClumpStream(2, 4, 3, func(record string, clump Clump) {
+		clumps = append(clumps, clump)
+	})
+	assert.NoError(t, err)
+	// bases can be added without Scan, as a record with an empty name
+	for _, b := range []byte("CCCCAAAAAA") {
+		s.AddBase(b)
+	}
+	assert.Equal(t, []Clump{{Pattern: "CC", Start: 0, End: 4, Occurrences: []int{0, 1, 2}}}, clumps)
+}
+
+func TestStreamClumps_gap(t *testing.T) {
+	fasta := ">x\nACGT" + strings.Repeat("N", 30) + "ACGT\n"
+	clumps := []Clump{}
+	err := StreamClumps(strings.NewReader(fasta), 3, 10, 3, func(record string, clump Clump) {
+		clumps = append(clumps, clump)
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, clumps)
+
+	// positions still count the gap, and no k-mer spans it
+	clumps = []Clump{}
+	err = StreamClumps(strings.NewReader("CCCC\nNN\nCCCC\n"), 2, 4, 3, func(record string, clump Clump) {
+		clumps = append(clumps, clump)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []Clump{
+		{Pattern: "CC", Start: 0, End: 4, Occurrences: []int{0, 1, 2}},
+		{Pattern: "CC", Start: 6, End: 10, Occurrences: []int{6, 7, 8}},
+	}, clumps)
+}
+
+func TestClumpStream_addBaseThenScan(t *testing.T) {
+	records := []string{}
+	s, err := NewClumpStream(2, 4, 3, func(record string, clump Clump) {
+		records = append(records, record)
+	})
+	assert.NoError(t, err)
+	for _, b := range []byte("CCCC") {
+		s.AddBase(b)
+	}
+	// the clump still open in the added bases is reported before Scan reads its own records
+	assert.NoError(t, s.Scan(strings.NewReader(">b\nGGGG\n")))
+	assert.Equal(t, []string{"", "b"}, records)
+}
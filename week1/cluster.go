@@ -0,0 +1,241 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// SquaredDistance is the squared Euclidean distance between a and b.
+func SquaredDistance(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
+
+/*
+   KMeans(Points, k)
+       Centers ← k points picked by k-means++
+       repeat until no point changes cluster
+           assign every point to its nearest center
+           move every center to the mean of its points
+*/
+
+// KMeans clusters points into k clusters, and returns the cluster of each point.
+// Clusters are numbered in the order their first point appears.
+func KMeans(points [][]float64, k, iterations int, random *rand.Rand) []int {
+	assignments := make([]int, len(points))
+	if len(points) == 0 || k < 1 {
+		return assignments
+	}
+	k = Min(k, len(points))
+
+	centers := kMeansPlusPlus(points, k, random)
+	for i := range assignments {
+		assignments[i] = -1
+	}
+
+	for iteration := 0; iteration < iterations; iteration++ {
+		changed := false
+		for i, point := range points {
+			nearest := nearestCenter(point, centers)
+			if nearest != assignments[i] {
+				assignments[i] = nearest
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		sums := make([][]float64, k)
+		counts := make([]int, k)
+		for c := range sums {
+			sums[c] = make([]float64, len(points[0]))
+		}
+		for i, point := range points {
+			c := assignments[i]
+			counts[c]++
+			for j, x := range point {
+				sums[c][j] += x
+			}
+		}
+		for c := range centers {
+			// an empty cluster keeps its center
+			if counts[c] == 0 {
+				continue
+			}
+			for j := range sums[c] {
+				sums[c][j] /= float64(counts[c])
+			}
+			centers[c] = sums[c]
+		}
+	}
+
+	return renumberClusters(assignments)
+}
+
+// kMeansPlusPlus picks the first center at random, and every next center
+// with probability proportional to its squared distance to the nearest center picked so far.
+func kMeansPlusPlus(points [][]float64, k int, random *rand.Rand) [][]float64 {
+	centers := [][]float64{points[random.Intn(len(points))]}
+
+	distances := make([]float64, len(points))
+	for len(centers) < k {
+		sum := 0.0
+		for i, point := range points {
+			distances[i] = SquaredDistance(point, centers[nearestCenter(point, centers)])
+			sum += distances[i]
+		}
+		if sum == 0 {
+			// all points are on a center already
+			centers = append(centers, points[random.Intn(len(points))])
+			continue
+		}
+		normalize(distances)
+		centers = append(centers, points[sampleIndex(distances, random)])
+	}
+
+	// copy, so moving a center doesn't change the points
+	for c := range centers {
+		centers[c] = append([]float64{}, centers[c]...)
+	}
+	return centers
+}
+
+func nearestCenter(point []float64, centers [][]float64) int {
+	nearest, best := 0, math.Inf(1)
+	for c, center := range centers {
+		if distance := SquaredDistance(point, center); distance < best {
+			nearest, best = c, distance
+		}
+	}
+	return nearest
+}
+
+// renumberClusters numbers clusters in the order their first member appears.
+func renumberClusters(assignments []int) []int {
+	numbers := map[int]int{}
+	for i, cluster := range assignments {
+		number, ok := numbers[cluster]
+		if !ok {
+			number = len(numbers)
+			numbers[cluster] = number
+		}
+		assignments[i] = number
+	}
+	return assignments
+}
+
+// Merge joins clusters A and B at Height in a Dendrogram.
+// The leaves are clusters 0 to Size-1, and the cluster made by merge i is Size+i.
+type Merge struct {
+	A, B   int
+	Height float64
+}
+
+// Dendrogram is the tree of merges made by agglomerative clustering, lowest first.
+type Dendrogram struct {
+	Size   int
+	Merges []Merge
+}
+
+/*
+   AverageLinkage(Distances)
+       every item is a cluster
+       while there is more than one cluster
+           merge the two closest clusters
+           the distance to the new cluster is the average distance to the items in it
+*/
+
+// AverageLinkage clusters items bottom up from a symmetric matrix of distances between them (UPGMA).
+// Ties are merged in the order of the lowest numbered clusters.
+func AverageLinkage(distances [][]float64) Dendrogram {
+	n := len(distances)
+	dendrogram := Dendrogram{Size: n, Merges: []Merge{}}
+
+	// dist[i][j] is the distance between active clusters i and j, by the row they started in
+	dist := make([][]float64, n)
+	for i := range dist {
+		dist[i] = append([]float64{}, distances[i]...)
+	}
+	active := make([]bool, n)
+	sizes := make([]int, n)
+	ids := make([]int, n)
+	for i := range active {
+		active[i], sizes[i], ids[i] = true, 1, i
+	}
+
+	for merge := 0; merge < n-1; merge++ {
+		bestI, bestJ, best := -1, -1, math.Inf(1)
+		for i := 0; i < n; i++ {
+			if !active[i] {
+				continue
+			}
+			for j := i + 1; j < n; j++ {
+				if active[j] && dist[i][j] < best {
+					bestI, bestJ, best = i, j, dist[i][j]
+				}
+			}
+		}
+
+		a, b := ids[bestI], ids[bestJ]
+		dendrogram.Merges = append(dendrogram.Merges, Merge{A: Min(a, b), B: Max(a, b), Height: best})
+
+		// the merged cluster takes the place of bestI
+		for k := 0; k < n; k++ {
+			if active[k] && k != bestI && k != bestJ {
+				d := (dist[bestI][k]*float64(sizes[bestI]) + dist[bestJ][k]*float64(sizes[bestJ])) /
+					float64(sizes[bestI]+sizes[bestJ])
+				dist[bestI][k], dist[k][bestI] = d, d
+			}
+		}
+		sizes[bestI] += sizes[bestJ]
+		ids[bestI] = n + merge
+		active[bestJ] = false
+	}
+
+	return dendrogram
+}
+
+// Clusters cuts the dendrogram into k clusters, and returns the cluster of each item.
+func (d Dendrogram) Clusters(k int) []int {
+	return d.cut(Max(d.Size-Max(k, 1), 0), math.Inf(1))
+}
+
+// ClustersBelow cuts the dendrogram at height, so items joined at or below height are in the same cluster.
+func (d Dendrogram) ClustersBelow(height float64) []int {
+	return d.cut(len(d.Merges), height)
+}
+
+// cut applies the first merges, lowest first, up to height.
+func (d Dendrogram) cut(merges int, height float64) []int {
+	parents := make([]int, d.Size+len(d.Merges))
+	for i := range parents {
+		parents[i] = i
+	}
+	var root func(int) int
+	root = func(i int) int {
+		if parents[i] != i {
+			parents[i] = root(parents[i])
+		}
+		return parents[i]
+	}
+
+	for i, merge := range d.Merges[:Min(merges, len(d.Merges))] {
+		if merge.Height > height {
+			break
+		}
+		cluster := d.Size + i
+		parents[root(merge.A)] = cluster
+		parents[root(merge.B)] = cluster
+	}
+
+	assignments := make([]int, d.Size)
+	for i := range assignments {
+		assignments[i] = root(i)
+	}
+	return renumberClusters(assignments)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var clusterPoints = [][]float64{
+	{0, 0}, {10, 10}, {0.5, 0}, {10, 10.5}, {0, 0.5}, {20, 0}, {20.5, 0},
+}
+
+func TestKMeans(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		assignments := KMeans(clusterPoints, 3, 100, rand.New(rand.NewSource(seed)))
+		assert.Equal(t, []int{0, 1, 0, 1, 0, 2, 2}, assignments)
+	}
+}
+
+func TestKMeansMoreClustersThanPoints(t *testing.T) {
+	assignments := KMeans([][]float64{{0}, {1}}, 5, 10, rand.New(rand.NewSource(1)))
+	assert.Equal(t, []int{0, 1}, assignments)
+
+	assert.Equal(t, []int{}, KMeans([][]float64{}, 2, 10, rand.New(rand.NewSource(1))))
+}
+
+func TestAverageLinkage(t *testing.T) {
+	distances := [][]float64{
+		{0, 2, 6, 10},
+		{2, 0, 5, 9},
+		{6, 5, 0, 4},
+		{10, 9, 4, 0},
+	}
+
+	dendrogram := AverageLinkage(distances)
+	assert.Equal(t, 4, dendrogram.Size)
+	// {0,1} at 2, {2,3} at 4, then both at the average of 6, 10, 5 and 9
+	assert.Equal(t, []Merge{{A: 0, B: 1, Height: 2}, {A: 2, B: 3, Height: 4}, {A: 4, B: 5, Height: 7.5}}, dendrogram.Merges)
+
+	assert.Equal(t, []int{0, 0, 1, 1}, dendrogram.Clusters(2))
+	assert.Equal(t, []int{0, 0, 1, 2}, dendrogram.Clusters(3))
+	assert.Equal(t, []int{0, 1, 2, 3}, dendrogram.Clusters(10))
+	assert.Equal(t, []int{0, 0, 0, 0}, dendrogram.Clusters(1))
+
+	assert.Equal(t, []int{0, 0, 1, 2}, dendrogram.ClustersBelow(3))
+	assert.Equal(t, []int{0, 0, 1, 1}, dendrogram.ClustersBelow(4))
+	assert.Equal(t, []int{0, 0, 0, 0}, dendrogram.ClustersBelow(100))
+}
+
+func TestAverageLinkageEmpty(t *testing.T) {
+	dendrogram := AverageLinkage([][]float64{})
+	assert.Equal(t, []Merge{}, dendrogram.Merges)
+	assert.Equal(t, []int{}, dendrogram.Clusters(2))
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContigHeader is the metadata SPAdes writes in its contig names, like NODE_1_length_117494_cov_172.988_ID_5.
+type ContigHeader struct {
+	Node     int
+	Length   int
+	Coverage float64
+}
+
+// ParseSpadesHeader parses a SPAdes contig name, with or without the leading '>'.
+// Fields after the coverage, like the ID of scaffolds, are ignored.
+func ParseSpadesHeader(header string) (ContigHeader, error) {
+	fields := strings.Split(strings.TrimPrefix(strings.TrimSpace(header), ">"), "_")
+	if len(fields) < 6 || fields[0] != "NODE" || fields[2] != "length" || fields[4] != "cov" {
+		return ContigHeader{}, fmt.Errorf("not a SPAdes contig header: %q", header)
+	}
+
+	node, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return ContigHeader{}, fmt.Errorf("bad node in %q: %v", header, err)
+	}
+	length, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return ContigHeader{}, fmt.Errorf("bad length in %q: %v", header, err)
+	}
+	coverage, err := strconv.ParseFloat(fields[5], 64)
+	if err != nil {
+		return ContigHeader{}, fmt.Errorf("bad coverage in %q: %v", header, err)
+	}
+
+	return ContigHeader{Node: node, Length: length, Coverage: coverage}, nil
+}
+
+// contigHeader parses the header of a record, falling back to only the sequence length for other assemblers.
+func contigHeader(record *Fasta) ContigHeader {
+	header, err := ParseSpadesHeader(record.Raw())
+	if err != nil {
+		return ContigHeader{Length: len(record.Genome())}
+	}
+	return header
+}
+
+// ContigFilter leaves out short and low coverage contigs, which are often contamination or assembly artifacts.
+type ContigFilter struct {
+	MinLength   int
+	MinCoverage float64
+}
+
+// Accept tells if a contig with header and a sequence of seqLen bases passes the filter.
+func (f ContigFilter) Accept(header ContigHeader, seqLen int) bool {
+	return seqLen >= f.MinLength && header.Coverage >= f.MinCoverage
+}
+
+// ContigSkew is the skew of one contig of a sample.
+type ContigSkew struct {
+	Sample string
+	Name   string
+	ContigHeader
+	MinSkew      int
+	MinPositions []int
+	MaxSkew      int
+	MaxPositions []int
+	// Amplitude is MaxSkew - MinSkew.
+	Amplitude int
+	// Strength is Amplitude / sqrt(length). The range of a random walk grows with the square root of its length,
+	// so this compares the skew of contigs of different lengths.
+	Strength float64
+}
+
+// ContigSkews computes the skew of each contig of sample passing filter, with the strongest signal first.
+func ContigSkews(sample string, records []Fasta, filter ContigFilter) []ContigSkew {
+	skews := []ContigSkew{}
+	for i := range records {
+		record := &records[i]
+		header := contigHeader(record)
+		genome := record.Genome()
+		if len(genome) == 0 || !filter.Accept(header, len(genome)) {
+			continue
+		}
+
+		skew := Skew(NormalizeDNA(genome))
+		minPositions, minSkew := Minimum(skew)
+		maxPositions, maxSkew := MaximumPositions(skew)
+		skews = append(skews, ContigSkew{
+			Sample:       sample,
+			Name:         record.Name(),
+			ContigHeader: header,
+			MinSkew:      minSkew,
+			MinPositions: minPositions,
+			MaxSkew:      maxSkew,
+			MaxPositions: maxPositions,
+			Amplitude:    maxSkew - minSkew,
+			Strength:     float64(maxSkew-minSkew) / math.Sqrt(float64(len(genome))),
+		})
+	}
+
+	SortContigSkews(skews)
+	return skews
+}
+
+// SortContigSkews sorts by strength, strongest first, then by sample and node.
+func SortContigSkews(skews []ContigSkew) {
+	sort.SliceStable(skews, func(i, j int) bool {
+		a, b := skews[i], skews[j]
+		if a.Strength != b.Strength {
+			return a.Strength > b.Strength
+		}
+		if a.Sample != b.Sample {
+			return a.Sample < b.Sample
+		}
+		return a.Node < b.Node
+	})
+}
+
+// WriteContigSkews writes one line per contig, with the first position of minimum and maximum skew.
+func WriteContigSkews(w io.Writer, skews []ContigSkew) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "sample\tcontig\tnode\tlength\tcoverage\tmin_skew\tmin_pos\tmax_skew\tmax_pos\tamplitude\tstrength")
+	for _, skew := range skews {
+		fmt.Fprintf(out, "%v\t%v\t%d\t%d\t%g\t%d\t%d\t%d\t%d\t%d\t%.4f\n",
+			skew.Sample, skew.Name, skew.Node, skew.Length, skew.Coverage,
+			skew.MinSkew, skew.MinPositions[0], skew.MaxSkew, skew.MaxPositions[0],
+			skew.Amplitude, skew.Strength)
+	}
+	return out.Flush()
+}
+
+// sampleName is the file name of an assembly without directory and extension, like 16_S3 for fasta/16_S3.fasta.
+func sampleName(filename string) string {
+	base := filepath.Base(filename)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// contigsCommand reports the skew of the contigs of one or more assemblies, strongest first, as TSV to stdout.
+//
+//	contigs [-min-length 1000] [-min-cov 0] [-n 0] fasta/*.fasta
+func contigsCommand(args []string) error {
+	filter := ContigFilter{}
+	flags := flag.NewFlagSet("contigs", flag.ContinueOnError)
+	flags.IntVar(&filter.MinLength, "min-length", 1000, "leave out contigs shorter than this")
+	flags.Float64Var(&filter.MinCoverage, "min-cov", 0, "leave out contigs with lower coverage than this")
+	top := flags.Int("n", 0, "number of contigs reported, 0 for all")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("usage: contigs [flags] assembly.fasta...")
+	}
+
+	skews := []ContigSkew{}
+	for _, filename := range flags.Args() {
+		records, err := ReadFastaRecords(filename)
+		if err != nil {
+			return err
+		}
+		skews = append(skews, ContigSkews(sampleName(filename), records, filter)...)
+	}
+
+	SortContigSkews(skews)
+	if *top > 0 && *top < len(skews) {
+		skews = skews[:*top]
+	}
+	return WriteContigSkews(os.Stdout, skews)
+}
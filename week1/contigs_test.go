@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSpadesHeader(t *testing.T) {
+	header, err := ParseSpadesHeader(">NODE_1_length_117494_cov_172.988_ID_5")
+	assert.NoError(t, err)
+	assert.Equal(t, ContigHeader{Node: 1, Length: 117494, Coverage: 172.988}, header)
+
+	header, err = ParseSpadesHeader("NODE_12_length_48931_cov_8.74578")
+	assert.NoError(t, err)
+	assert.Equal(t, ContigHeader{Node: 12, Length: 48931, Coverage: 8.74578}, header)
+
+	_, err = ParseSpadesHeader(">gi|12345|Vibrio cholerae")
+	assert.Error(t, err)
+	_, err = ParseSpadesHeader(">NODE_1_length_many_cov_1.5")
+	assert.Error(t, err)
+}
+
+func TestContigSkews(t *testing.T) {
+	records, err := ParseFasta(strings.NewReader(
+		">NODE_1_length_12_cov_10.5\nCCCCCC\nGGGGGG\n" +
+			">NODE_2_length_12_cov_2\nCGCGCGCGCGCG\n" +
+			">NODE_3_length_4_cov_50\nCCCC\n" +
+			">other assembler\nGGGGGGCCCCCC\n"))
+	assert.NoError(t, err)
+
+	skews := ContigSkews("sample", records, ContigFilter{MinLength: 10})
+	assert.Len(t, skews, 3)
+
+	// as strong as node 1, the header without metadata sorts first as node 0
+	assert.Equal(t, "other assembler", skews[0].Name)
+	assert.Equal(t, ContigHeader{Length: 12}, skews[0].ContigHeader)
+	assert.Equal(t, []int{6}, skews[0].MaxPositions)
+
+	assert.Equal(t, "NODE_1_length_12_cov_10.5", skews[1].Name)
+	assert.Equal(t, ContigHeader{Node: 1, Length: 12, Coverage: 10.5}, skews[1].ContigHeader)
+	assert.Equal(t, []int{6}, skews[1].MinPositions)
+	assert.Equal(t, -6, skews[1].MinSkew)
+	assert.Equal(t, 6, skews[1].Amplitude)
+
+	assert.Equal(t, 2, skews[2].Node)
+	assert.Equal(t, 1, skews[2].Amplitude)
+
+	skews = ContigSkews("sample", records, ContigFilter{MinCoverage: 5})
+	assert.Len(t, skews, 2)
+	assert.Equal(t, 3, skews[0].Node)
+	assert.Equal(t, 1, skews[1].Node)
+}
+
+func TestWriteContigSkews(t *testing.T) {
+	records, err := ParseFasta(strings.NewReader(">NODE_1_length_4_cov_2.5\nCCGG\n"))
+	assert.NoError(t, err)
+
+	buf := bytes.Buffer{}
+	err = WriteContigSkews(&buf, ContigSkews("16_S3", records, ContigFilter{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "sample\tcontig\tnode\tlength\tcoverage\tmin_skew\tmin_pos\tmax_skew\tmax_pos\tamplitude\tstrength\n"+
+		"16_S3\tNODE_1_length_4_cov_2.5\t1\t4\t2.5\t-2\t2\t0\t0\t2\t1.0000\n", buf.String())
+}
+
+func TestSampleName(t *testing.T) {
+	assert.Equal(t, "16_S3", sampleName("fasta/16_S3.fasta"))
+	assert.Equal(t, "genome", sampleName("genome"))
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// SkewWindow holds the base composition of one window of the genome.
+type SkewWindow struct {
+	Start int
+	End   int
+	// GC is the fraction of G and C.
+	GC float64
+	// GCSkew is (G-C)/(G+C), 0 when the window has no G or C.
+	GCSkew float64
+	// ATSkew is (A-T)/(A+T), 0 when the window has no A or T.
+	ATSkew float64
+}
+
+// Center is the middle of the window.
+func (w SkewWindow) Center() int {
+	return (w.Start + w.End) / 2
+}
+
+// SkewProfile is the GC content and skews along a genome, in sliding windows.
+type SkewProfile struct {
+	WindowLength int
+	Step         int
+	Windows      []SkewWindow
+	// Origin holds the positions of minimum cumulative skew, and Terminus the positions of maximum skew.
+	Origin   []int
+	MinSkew  int
+	Terminus []int
+	MaxSkew  int
+	// MinWindow and MaxWindow are the windows with the lowest and highest GC skew.
+	MinWindow SkewWindow
+	MaxWindow SkewWindow
+	// RisingCrossings are where the GC skew goes from negative to positive, like at the origin,
+	// and FallingCrossings where it goes from positive to negative, like at the terminus.
+	RisingCrossings  []int
+	FallingCrossings []int
+}
+
+// NewSkewProfile computes the base composition in windows of windowLength, moved step bases at a time.
+func NewSkewProfile(normDNA []byte, windowLength, step int) SkewProfile {
+	dnaLen := len(normDNA)
+	windowLength = Max(Min(windowLength, dnaLen), 1)
+	step = Max(step, 1)
+
+	profile := SkewProfile{
+		WindowLength:     windowLength,
+		Step:             step,
+		Windows:          []SkewWindow{},
+		RisingCrossings:  []int{},
+		FallingCrossings: []int{},
+	}
+	profile.Origin, profile.MinSkew = MinSkew(normDNA)
+	profile.Terminus, profile.MaxSkew = MaxSkew(normDNA)
+	if dnaLen == 0 {
+		return profile
+	}
+
+	// counts[i][b] is the number of base b before position i
+	counts := make([][4]int, dnaLen+1)
+	for i, bp := range normDNA {
+		counts[i+1] = counts[i]
+		counts[i+1][bp]++
+	}
+
+	for start := 0; start+windowLength <= dnaLen; start += step {
+		end := start + windowLength
+		var bases [4]int
+		for b := range bases {
+			bases[b] = counts[end][b] - counts[start][b]
+		}
+		a, c, g, t := bases[0], bases[1], bases[2], bases[3]
+
+		profile.Windows = append(profile.Windows, SkewWindow{
+			Start:  start,
+			End:    end,
+			GC:     float64(g+c) / float64(windowLength),
+			GCSkew: skewRatio(g, c),
+			ATSkew: skewRatio(a, t),
+		})
+	}
+
+	profile.MinWindow = profile.Windows[0]
+	profile.MaxWindow = profile.Windows[0]
+	sign := 0
+	for i, window := range profile.Windows {
+		if window.GCSkew < profile.MinWindow.GCSkew {
+			profile.MinWindow = window
+		}
+		if window.GCSkew > profile.MaxWindow.GCSkew {
+			profile.MaxWindow = window
+		}
+
+		current := 0
+		if window.GCSkew > 0 {
+			current = 1
+		} else if window.GCSkew < 0 {
+			current = -1
+		}
+		if current == 0 {
+			continue
+		}
+		if sign != 0 && current != sign {
+			crossing := (profile.Windows[i-1].Center() + window.Center()) / 2
+			if current > 0 {
+				profile.RisingCrossings = append(profile.RisingCrossings, crossing)
+			} else {
+				profile.FallingCrossings = append(profile.FallingCrossings, crossing)
+			}
+		}
+		sign = current
+	}
+
+	return profile
+}
+
+func skewRatio(x, y int) float64 {
+	if x+y == 0 {
+		return 0
+	}
+	return float64(x-y) / float64(x+y)
+}
+
+// WriteTSV writes one line per window with start, end, GC content, GC skew and AT skew.
+func (p *SkewProfile) WriteTSV(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "start\tend\tgc\tgc_skew\tat_skew")
+	for _, window := range p.Windows {
+		fmt.Fprintf(out, "%d\t%d\t%.4f\t%.4f\t%.4f\n", window.Start, window.End, window.GC, window.GCSkew, window.ATSkew)
+	}
+	return out.Flush()
+}
+
+// SkewTrack selects the value written to a bedGraph track.
+type SkewTrack int
+
+const (
+	GCTrack SkewTrack = iota
+	GCSkewTrack
+	ATSkewTrack
+)
+
+func (t SkewTrack) String() string {
+	switch t {
+	case GCSkewTrack:
+		return "GC skew"
+	case ATSkewTrack:
+		return "AT skew"
+	}
+	return "GC content"
+}
+
+func (t SkewTrack) value(window SkewWindow) float64 {
+	switch t {
+	case GCSkewTrack:
+		return window.GCSkew
+	case ATSkewTrack:
+		return window.ATSkew
+	}
+	return window.GC
+}
+
+// WriteBedGraph writes a bedGraph track for chrom.
+// bedGraph intervals may not overlap, so each window is written as the step wide interval around its center.
+func (p *SkewProfile) WriteBedGraph(w io.Writer, chrom string, track SkewTrack) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "track type=bedGraph name=\"%v %v\"\n", chrom, track)
+
+	for i, window := range p.Windows {
+		start := window.Center() - p.Step/2
+		end := start + p.Step
+		if i == 0 {
+			start = 0
+		}
+		if i == len(p.Windows)-1 {
+			end = window.End
+		}
+		fmt.Fprintf(out, "%v\t%d\t%d\t%.4f\n", chrom, Max(start, 0), end, track.value(window))
+	}
+	return out.Flush()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSkewProfile(t *testing.T) {
+	// C rich, then G rich, then C rich again
+	dna := NormalizeDNA("CCCCAT" + "GGGGAT" + "CCCCAA")
+
+	profile := NewSkewProfile(dna, 6, 3)
+
+	assert.Len(t, profile.Windows, 5)
+	assert.Equal(t, SkewWindow{Start: 0, End: 6, GC: 4.0 / 6, GCSkew: -1, ATSkew: 0}, profile.Windows[0])
+	assert.Equal(t, SkewWindow{Start: 6, End: 12, GC: 4.0 / 6, GCSkew: 1, ATSkew: 0}, profile.Windows[2])
+	assert.Equal(t, SkewWindow{Start: 12, End: 18, GC: 4.0 / 6, GCSkew: -1, ATSkew: 1}, profile.Windows[4])
+
+	assert.Equal(t, []int{4, 5, 6, 16, 17, 18}, profile.Origin)
+	assert.Equal(t, -4, profile.MinSkew)
+	assert.Equal(t, []int{0, 10, 11, 12}, profile.Terminus)
+	assert.Equal(t, 0, profile.MaxSkew)
+
+	assert.Equal(t, 0, profile.MinWindow.Start)
+	assert.Equal(t, 6, profile.MaxWindow.Start)
+	assert.Equal(t, []int{4}, profile.RisingCrossings)
+	assert.Equal(t, []int{10}, profile.FallingCrossings)
+}
+
+func TestMaxSkew(t *testing.T) {
+	pos, val := MaxSkew(NormalizeDNA("CATGGGCATCGGCCATACGCC"))
+	assert.Equal(t, []int{6, 12}, pos)
+	assert.Equal(t, 2, val)
+}
+
+func TestSkewProfileWriteTSV(t *testing.T) {
+	profile := NewSkewProfile(NormalizeDNA("GGGGCCCC"), 4, 4)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, profile.WriteTSV(&buf))
+	assert.Equal(t,
+		"start\tend\tgc\tgc_skew\tat_skew\n"+
+			"0\t4\t1.0000\t1.0000\t0.0000\n"+
+			"4\t8\t1.0000\t-1.0000\t0.0000\n",
+		buf.String())
+}
+
+func TestSkewProfileWriteBedGraph(t *testing.T) {
+	profile := NewSkewProfile(NormalizeDNA(strings.Repeat("G", 10)+strings.Repeat("C", 10)), 10, 5)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, profile.WriteBedGraph(&buf, "chr1", GCSkewTrack))
+	assert.Equal(t,
+		"track type=bedGraph name=\"chr1 GC skew\"\n"+
+			"chr1\t0\t8\t1.0000\n"+
+			"chr1\t8\t13\t0.0000\n"+
+			"chr1\t13\t20\t-1.0000\n",
+		buf.String())
+}
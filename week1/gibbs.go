@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+)
+
+// GibbsOptions configures GibbsSamplerRestarts.
+type GibbsOptions struct {
+	MotifSearchOptions
+	Iterations int
+	// BurnIn is the number of iterations run before the best motifs are tracked,
+	// so the random starting motifs are not mistaken for a result.
+	BurnIn int
+}
+
+// DefaultGibbsOptions runs 20 restarts of 2000 iterations, with a burn-in of 100 iterations.
+func DefaultGibbsOptions() GibbsOptions {
+	return GibbsOptions{
+		MotifSearchOptions: MotifSearchOptions{Restarts: 20},
+		Iterations:         2000,
+		BurnIn:             100,
+	}
+}
+
+func (opts GibbsOptions) validate() error {
+	if opts.Iterations < 0 {
+		return fmt.Errorf("iterations must be at least 0, got %v", opts.Iterations)
+	}
+	if opts.BurnIn < 0 {
+		return fmt.Errorf("burn-in must be at least 0, got %v", opts.BurnIn)
+	}
+	return nil
+}
+
+// validateGibbs checks dna and k like the other motif searches, and that there is a string to leave out of the profile.
+func validateGibbs(dna sequences, k int, opts GibbsOptions) error {
+	if err := validateMotifSearch(dna, k); err != nil {
+		return err
+	}
+	if len(dna) < 2 {
+		return fmt.Errorf("the Gibbs sampler needs at least 2 sequences, got %v", len(dna))
+	}
+	return opts.validate()
+}
+
+/*
+   GibbsSampler(Dna, k, t, N)
+       randomly select k-mers Motifs = (Motif1, …, Motift) in each string from Dna
+       BestMotifs ← Motifs
+       for j ← 1 to N
+           i ← Random(t)
+           Profile ← profile matrix constructed from all strings in Motifs except for Motifi
+           Motifi ← Profile-randomly generated k-mer in the i-th sequence
+           if Score(Motifs) < Score(BestMotifs)
+               BestMotifs ← Motifs
+       return BestMotifs
+*/
+
+// GibbsSampler starts from a random k-mer in each string of dna. In each iteration it leaves out the motif of one string,
+// makes a profile with pseudocounts from the others, and picks a new motif for that string at random,
+// in proportion to the probability of each k-mer under the profile.
+// It returns the best motifs seen after the burn-in, their Score, and the best score after every iteration,
+// or no motifs when there are fewer than 2 strings, a string is shorter than k, or iterations or burnIn is negative.
+func GibbsSampler(dna sequences, k, iterations, burnIn int, random *rand.Rand) (sequences, int, []int) {
+	if err := validateGibbs(dna, k, GibbsOptions{Iterations: iterations, BurnIn: burnIn}); err != nil {
+		return sequences{}, 0, []int{}
+	}
+	return gibbsSampler(context.Background(), dna, k, iterations, burnIn, random)
+}
+
+// GibbsSamplerRestarts runs GibbsSampler from many random starts, and keeps the best motifs.
+func GibbsSamplerRestarts(ctx context.Context, dna sequences, k int, source rand.Source, opts GibbsOptions) (MotifSearchResult, error) {
+	if err := validateGibbs(dna, k, opts); err != nil {
+		return MotifSearchResult{}, err
+	}
+	return runRestarts(ctx, source, opts.MotifSearchOptions, func(ctx context.Context, random *rand.Rand) (sequences, int, []int) {
+		return gibbsSampler(ctx, dna, k, opts.Iterations, opts.BurnIn, random)
+	})
+}
+
+func gibbsSampler(ctx context.Context, dna sequences, k, iterations, burnIn int, random *rand.Rand) (sequences, int, []int) {
+	motifs := randomMotifs(dna, k, random)
+	burnIn = Max(Min(burnIn, iterations), 0)
+
+	var bestMotifs sequences
+	bestScore := -1
+	trace := make([]int, 0, Max(iterations-burnIn, 0))
+
+	others := make(sequences, 0, len(dna)-1)
+	for j := 0; j < iterations && ctx.Err() == nil; j++ {
+		i := random.Intn(len(dna))
+
+		others = append(others[:0], motifs[:i]...)
+		others = append(others, motifs[i+1:]...)
+		// the callers checked dna, so there is at least one other motif and the profile is valid
+		profile, _ := NewProfileFromMotifs(others, 1)
+		motifs[i] = ProfileRandomKmer(dna[i], k, profile, random)
+
+		if j < burnIn {
+			continue
+		}
+		if score := Score(motifs); bestScore == -1 || score < bestScore {
+			bestMotifs, bestScore = append(sequences{}, motifs...), score
+		}
+		trace = append(trace, bestScore)
+	}
+
+	if bestMotifs == nil {
+		// no iterations after the burn-in
+		bestMotifs, bestScore = motifs, Score(motifs)
+	}
+	return bestMotifs, bestScore, trace
+}
+
+// ProfileRandomKmer picks a k-mer of dna at random, in proportion to its probability under the profile.
+// When every k-mer has probability 0, all are equally likely.
+func ProfileRandomKmer(dna sequence, k int, profile ProfileMatrix, random *rand.Rand) sequence {
+	probs := make([]float64, len(dna)-k+1)
+	sum := 0.0
+	for i := range probs {
+		probs[i] = profile.Score(dna[i : i+k])
+		sum += probs[i]
+	}
+
+	var start int
+	if sum == 0 {
+		start = random.Intn(len(probs))
+	} else {
+		normalize(probs)
+		start = sampleIndex(probs, random)
+	}
+	return dna[start : start+k]
+}
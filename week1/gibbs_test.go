@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGibbsSampler(t *testing.T) {
+	dna := NormalizeListDNA(randomizedMotifDataset)
+
+	motifs, score, trace := GibbsSampler(dna, 8, 200, 20, rand.New(rand.NewSource(1)))
+	assert.Len(t, motifs, 5)
+	assert.Equal(t, Score(motifs), score)
+	assert.Len(t, trace, 180)
+	assert.Equal(t, score, trace[len(trace)-1])
+	for i := 1; i < len(trace); i++ {
+		assert.True(t, trace[i] <= trace[i-1])
+	}
+}
+
+func TestGibbsSamplerBurnInLongerThanIterations(t *testing.T) {
+	dna := NormalizeListDNA(randomizedMotifDataset)
+
+	motifs, score, trace := GibbsSampler(dna, 8, 10, 20, rand.New(rand.NewSource(1)))
+	assert.Len(t, motifs, 5)
+	assert.Equal(t, Score(motifs), score)
+	assert.Equal(t, []int{}, trace)
+
+}
+
+func TestGibbsSamplerInvalid(t *testing.T) {
+	dna := NormalizeListDNA(randomizedMotifDataset)
+	for _, test := range []struct {
+		dna        sequences
+		iterations int
+		burnIn     int
+	}{
+		{dna, -1, 0},
+		{dna, 10, -1},
+		{NormalizeListDNA([]string{"ACGTACGT", "TT", "ACGTT"}), 10, 0},
+		{dna[:1], 10, 0},
+	} {
+		motifs, score, trace := GibbsSampler(test.dna, 3, test.iterations, test.burnIn, rand.New(rand.NewSource(1)))
+		assert.Equal(t, sequences{}, motifs)
+		assert.Equal(t, 0, score)
+		assert.Equal(t, []int{}, trace)
+	}
+}
+
+func TestGibbsSamplerRestarts(t *testing.T) {
+	dna := NormalizeListDNA(randomizedMotifDataset)
+	expected := []string{"TCTCGGGG", "CCAAGGTG", "TACAGGCG", "TTCAGGTG", "TCCACGTG"}
+
+	opts := GibbsOptions{MotifSearchOptions: MotifSearchOptions{Restarts: 50, Workers: 4}, Iterations: 500, BurnIn: 10}
+	result, err := GibbsSamplerRestarts(context.Background(), dna, 8, rand.NewSource(1), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, Score(NormalizeListDNA(expected)), result.Score)
+	assert.Len(t, result.Scores, 50)
+	assert.Len(t, result.Trace, 490)
+
+	opts.Workers = 1
+	again, err := GibbsSamplerRestarts(context.Background(), dna, 8, rand.NewSource(1), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, result, again)
+
+	_, err = GibbsSamplerRestarts(context.Background(), dna[:1], 8, rand.NewSource(1), opts)
+	assert.Error(t, err)
+
+	opts.Iterations = -1
+	_, err = GibbsSamplerRestarts(context.Background(), dna, 8, rand.NewSource(1), opts)
+	assert.EqualError(t, err, "iterations must be at least 0, got -1")
+
+	opts.Iterations, opts.BurnIn = 10, -1
+	_, err = GibbsSamplerRestarts(context.Background(), dna, 8, rand.NewSource(1), opts)
+	assert.EqualError(t, err, "burn-in must be at least 0, got -1")
+}
+
+func TestGibbsSamplerSubtleMotif(t *testing.T) {
+	file, err := os.Open("subtle_motif.txt")
+	assert.NoError(t, err)
+	defer file.Close()
+	dna, err := readMotifDataset(file)
+	assert.NoError(t, err)
+
+	result, err := GibbsSamplerRestarts(context.Background(), dna, 15, rand.NewSource(1), DefaultGibbsOptions())
+	assert.NoError(t, err)
+	// the implanted copies of AAAAAAAAGGGGGGG score 40. Randomized search doesn't get that low,
+	// the sampler finds motifs at least as good, overlapping the implanted ones
+	assert.True(t, result.Score <= 40, "score %v", result.Score)
+	consensus := DeNormalizeDNA(Consensus(result.Motifs))
+	assert.Contains(t, consensus, "AAAAAA")
+	assert.Contains(t, consensus, "GGGG")
+}
+
+func TestProfileRandomKmer(t *testing.T) {
+	profile, err := NewProfileMatrix([][]float64{{1, 1}, {0, 0}, {0, 0}, {0, 0}})
+	assert.NoError(t, err)
+	random := rand.New(rand.NewSource(1))
+
+	// AA is the only k-mer with a probability
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "AA", DeNormalizeDNA(ProfileRandomKmer(NormalizeDNA("CGAACG"), 2, profile, random)))
+	}
+
+	// all zero, any k-mer
+	kmer := ProfileRandomKmer(NormalizeDNA("CGTC"), 2, profile, random)
+	assert.Len(t, kmer, 2)
+}
@@ -175,16 +175,5 @@ func ApproximateSubStringCount(text, pattern string, distance int) int {
 }
 
 func NaiveApproximateSubString(text, pattern string, distance int) []int {
-	positions := make([]int, 0, 5)
-	patLen := len(pattern)
-	textLen := len(text)
-
-	for i := 0; i < textLen-patLen+1; i++ {
-		window := text[i : i+patLen]
-		if HammingDistanceStr(window, pattern) <= distance {
-			positions = append(positions, i)
-		}
-	}
-
-	return positions
+	return HitPositions(ApproximateSubStringHits(text, pattern, distance, false))
 }
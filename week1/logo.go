@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+// Logo is a sequence logo. At each position the bases are stacked by frequency,
+// and the stack is as high as the information content of the position.
+type Logo struct {
+	Title string
+	// Heights[pos][nuc] is the height of each base in bits.
+	Heights [][]float64
+}
+
+// LogoOptions configures NewLogo.
+type LogoOptions struct {
+	// Sites is the number of sequences the profile was made from, for the small-sample correction.
+	// 0 leaves the correction out.
+	Sites int
+	// ReverseComplement draws the motif as it reads on the other strand.
+	ReverseComplement bool
+}
+
+/*
+   Logo(Profile, n)
+       for each position i
+           R(i) ← 2 - (Entropy(column i) + e(n))
+           for each base b
+               Height(b, i) ← Profile(b, i) · R(i)
+*/
+
+// SmallSampleCorrection is the entropy a column of a uniform profile loses when it is estimated from only sites sequences,
+// (4-1) / (2·ln 2·sites) bits. Without it a motif made from a few sites looks more conserved than it is.
+func SmallSampleCorrection(sites int) float64 {
+	if sites <= 0 {
+		return 0
+	}
+	return 3 / (2 * math.Ln2 * float64(sites))
+}
+
+// NewLogo makes the logo of profile, with letter heights of frequency times information content.
+func NewLogo(title string, profile ProfileMatrix, opts LogoOptions) *Logo {
+	k := profile.K()
+	correction := SmallSampleCorrection(opts.Sites)
+	logo := &Logo{Title: title, Heights: make([][]float64, k)}
+	for pos := 0; pos < k; pos++ {
+		entropy := 0.0
+		for nuc := 0; nuc < 4; nuc++ {
+			if p := profile.Get(nuc, pos); p > 0 {
+				entropy -= p * math.Log2(p)
+			}
+		}
+		information := math.Max(2-entropy-correction, 0)
+
+		column := pos
+		if opts.ReverseComplement {
+			column = k - 1 - pos
+		}
+		logo.Heights[column] = make([]float64, 4)
+		for nuc := 0; nuc < 4; nuc++ {
+			height := profile.Get(nuc, pos) * information
+			if opts.ReverseComplement {
+				logo.Heights[column][3-nuc] = height
+			} else {
+				logo.Heights[column][nuc] = height
+			}
+		}
+	}
+	return logo
+}
+
+// NewLogoFromMotifs makes the logo of the profile of motifs, corrected for the number of motifs.
+func NewLogoFromMotifs(title string, motifs sequences, reverseComplement bool) (*Logo, error) {
+	profile, err := NewProfileFromMotifs(motifs, 0)
+	if err != nil {
+		return nil, err
+	}
+	return NewLogo(title, profile, LogoOptions{Sites: len(motifs), ReverseComplement: reverseComplement}), nil
+}
+
+// InformationContent is the height in bits of the stack at each position.
+func (l *Logo) InformationContent() []float64 {
+	information := make([]float64, len(l.Heights))
+	for pos, heights := range l.Heights {
+		for _, height := range heights {
+			information[pos] += height
+		}
+	}
+	return information
+}
+
+// logoGlyphs are the outlines of the bases in a unit box, drawn with the even-odd rule so the A has a hole.
+var logoGlyphs = []string{
+	"M 0 1 L 0.4 0 L 0.6 0 L 1 1 L 0.78 1 L 0.68 0.72 L 0.32 0.72 L 0.22 1 Z M 0.38 0.55 L 0.62 0.55 L 0.5 0.22 Z",
+	"M 0.93 0.28 A 0.5 0.5 0 1 0 0.93 0.72 L 0.76 0.64 A 0.3 0.32 0 1 1 0.76 0.36 Z",
+	"M 0.93 0.28 A 0.5 0.5 0 1 0 1 0.55 L 1 0.48 L 0.55 0.48 L 0.55 0.62 L 0.79 0.62 A 0.3 0.32 0 1 1 0.76 0.36 Z",
+	"M 0 0 L 1 0 L 1 0.18 L 0.6 0.18 L 0.6 1 L 0.4 1 L 0.4 0.18 L 0 0.18 Z",
+}
+
+var logoColors = []string{"#109648", "#255c99", "#f7b32b", "#d62839"}
+
+// WriteSVG draws the logo as an SVG image height pixels high, with columns of columnWidth pixels.
+// The letters are drawn as outlines, so they fill their stack exactly whatever fonts the viewer has.
+func (l *Logo) WriteSVG(w io.Writer, columnWidth, height int) error {
+	out := bufio.NewWriter(w)
+	col := float64(columnWidth)
+	left, right, top, bottom := 40.0, 10.0, 30.0, 25.0
+	width := left + right + col*float64(len(l.Heights))
+	plotHeight := float64(height) - top - bottom
+	bit := plotHeight / 2
+	base := top + plotHeight
+
+	fmt.Fprintf(out, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%d" viewBox="0 0 %.0f %d">`+"\n", width, height, width, height)
+	fmt.Fprintf(out, `<rect width="%.0f" height="%d" fill="white"/>`+"\n", width, height)
+	fmt.Fprintf(out, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="14">%v</text>`+"\n",
+		width/2, top/2+5, html.EscapeString(l.Title))
+
+	// the information axis, from 0 to 2 bits
+	fmt.Fprintf(out, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="black"/>`+"\n", left-5, top, left-5, base)
+	for bits := 0; bits <= 2; bits++ {
+		y := base - float64(bits)*bit
+		fmt.Fprintf(out, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="black"/>`+"\n", left-10, y, left-5, y)
+		fmt.Fprintf(out, `<text x="%.1f" y="%.1f" text-anchor="end" dominant-baseline="middle" font-family="sans-serif" font-size="11">%d</text>`+"\n",
+			left-12, y, bits)
+	}
+	fmt.Fprintf(out, `<text x="12" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="11" transform="rotate(-90 12 %.1f)">bits</text>`+"\n",
+		top+plotHeight/2, top+plotHeight/2)
+
+	for pos, heights := range l.Heights {
+		x := left + float64(pos)*col
+		fmt.Fprintf(out, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="11">%d</text>`+"\n",
+			x+col/2, base+15, pos+1)
+
+		// the most frequent base goes on top
+		order := []int{0, 1, 2, 3}
+		sort.SliceStable(order, func(i, j int) bool {
+			return heights[order[i]] < heights[order[j]]
+		})
+		y := base
+		for _, nuc := range order {
+			h := heights[nuc] * bit
+			if h < 0.01 {
+				continue
+			}
+			y -= h
+			fmt.Fprintf(out, `<path d="%v" fill="%v" fill-rule="evenodd" transform="translate(%.2f %.2f) scale(%.2f %.4f)"/>`+"\n",
+				logoGlyphs[nuc], logoColors[nuc], x+col*0.05, y, col*0.9, h)
+		}
+	}
+
+	fmt.Fprintln(out, "</svg>")
+	return out.Flush()
+}
+
+// logoCommand draws the logo of a motif file, or of a file of aligned sites with -sites, as SVG to stdout.
+//
+//	logo [-id ID] [-sites] [-rc] [-column 30] [-height 200] motif.jaspar
+func logoCommand(args []string) error {
+	flags := flag.NewFlagSet("logo", flag.ContinueOnError)
+	id := flags.String("id", "", "ID of the motif in the file, the first one by default")
+	sitesFile := flags.Bool("sites", false, "the file holds aligned sites, one per line, instead of a motif")
+	reverse := flags.Bool("rc", false, "draw the reverse complement of the motif")
+	column := flags.Int("column", 30, "width of a position in pixels")
+	height := flags.Int("height", 200, "height of the image in pixels")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: logo [flags] motif-file")
+	}
+
+	var logo *Logo
+	if *sitesFile {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		sites, err := readMotifDataset(file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		if logo, err = NewLogoFromMotifs(sampleName(flags.Arg(0)), sites, *reverse); err != nil {
+			return err
+		}
+	} else {
+		motif, err := readMotif(flags.Arg(0), *id)
+		if err != nil {
+			return err
+		}
+		profile, err := motif.Profile(0)
+		if err != nil {
+			return err
+		}
+		title := motif.ID
+		if motif.Name != "" {
+			title += " " + motif.Name
+		}
+		logo = NewLogo(title, profile, LogoOptions{Sites: int(math.Round(motif.Sites())), ReverseComplement: *reverse})
+	}
+	if *reverse {
+		logo.Title += " (reverse complement)"
+	}
+	return logo.WriteSVG(os.Stdout, *column, *height)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmallSampleCorrection(t *testing.T) {
+	assert.Equal(t, 0.0, SmallSampleCorrection(0))
+	assert.InDelta(t, 3/(2*math.Ln2*4), SmallSampleCorrection(4), 1e-12)
+	assert.True(t, SmallSampleCorrection(100) < SmallSampleCorrection(10))
+}
+
+func TestNewLogo(t *testing.T) {
+	profile, err := NewProfileMatrix([][]float64{
+		{1, 0.25, 0.5},
+		{0, 0.25, 0.5},
+		{0, 0.25, 0},
+		{0, 0.25, 0},
+	})
+	assert.NoError(t, err)
+
+	logo := NewLogo("test", profile, LogoOptions{})
+	assert.Equal(t, []float64{2, 0, 0, 0}, logo.Heights[0])
+	assert.Equal(t, []float64{0, 0, 0, 0}, logo.Heights[1])
+	assert.Equal(t, []float64{0.5, 0.5, 0, 0}, logo.Heights[2])
+	assert.Equal(t, []float64{2, 0, 1}, logo.InformationContent())
+
+	// the correction is taken off every column, and uninformative columns stay at 0
+	corrected := NewLogo("test", profile, LogoOptions{Sites: 10})
+	information := corrected.InformationContent()
+	assert.InDelta(t, 2-SmallSampleCorrection(10), information[0], 1e-12)
+	assert.Equal(t, 0.0, information[1])
+	assert.InDelta(t, 1-SmallSampleCorrection(10), information[2], 1e-12)
+
+	// on the other strand the last column comes first, with the bases complemented
+	reverse := NewLogo("test", profile, LogoOptions{ReverseComplement: true})
+	assert.Equal(t, []float64{0, 0, 0.5, 0.5}, reverse.Heights[0])
+	assert.Equal(t, []float64{0, 0, 0, 2}, reverse.Heights[2])
+}
+
+func TestNewLogoFromMotifs(t *testing.T) {
+	motifs := NormalizeListDNA([]string{"TTATCCACA", "TTATCCAAA", "TTTTCCACA", "GTATCAACA"})
+	logo, err := NewLogoFromMotifs("DnaA", motifs, false)
+	assert.NoError(t, err)
+	assert.Len(t, logo.Heights, 9)
+	assert.InDelta(t, 2-SmallSampleCorrection(4), logo.Heights[1][3], 1e-12)
+
+	_, err = NewLogoFromMotifs("empty", sequences{}, false)
+	assert.Error(t, err)
+}
+
+func TestLogoWriteSVG(t *testing.T) {
+	motifs := NormalizeListDNA([]string{"ACGT", "ACGA", "ACTT"})
+	logo, err := NewLogoFromMotifs("<logo>", motifs, false)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, logo.WriteSVG(&buf, 20, 150))
+	svg := buf.String()
+	elements := svgElements(t, svg)
+	assert.Equal(t, 1, elements["svg"])
+	// one letter in each of the first two columns, two in each of the last two
+	assert.Equal(t, 6, elements["path"])
+	assert.Contains(t, svg, "&lt;logo&gt;")
+	assert.Contains(t, svg, `width="130"`)
+}
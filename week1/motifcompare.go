@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+// compareBins is the number of bins the column scores of a comparison are rounded to for its p-value.
+const compareBins = 50
+
+// ColumnSimilarity scores how alike two columns of profiles are, higher for more alike columns.
+type ColumnSimilarity int
+
+const (
+	// PearsonSimilarity is the Pearson correlation of the base probabilities of the columns.
+	PearsonSimilarity ColumnSimilarity = iota
+	// KLSimilarity is minus the symmetric Kullback-Leibler divergence of the columns in bits.
+	KLSimilarity
+	// EuclideanSimilarity is minus the Euclidean distance between the columns.
+	EuclideanSimilarity
+)
+
+func (s ColumnSimilarity) String() string {
+	switch s {
+	case KLSimilarity:
+		return "kl"
+	case EuclideanSimilarity:
+		return "euclidean"
+	}
+	return "pearson"
+}
+
+// ParseColumnSimilarity is the similarity called name, as printed by String.
+func ParseColumnSimilarity(name string) (ColumnSimilarity, error) {
+	for _, s := range []ColumnSimilarity{PearsonSimilarity, KLSimilarity, EuclideanSimilarity} {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return PearsonSimilarity, fmt.Errorf("unknown column similarity %q", name)
+}
+
+// Score is the similarity of columns p and q, each the probabilities of the 4 bases.
+func (s ColumnSimilarity) Score(p, q []float64) float64 {
+	switch s {
+	case KLSimilarity:
+		divergence := 0.0
+		for nuc := range p {
+			pp, qq := math.Max(p[nuc], pwmMinProbability), math.Max(q[nuc], pwmMinProbability)
+			divergence += (pp - qq) * math.Log2(pp/qq)
+		}
+		return -divergence / 2
+	case EuclideanSimilarity:
+		return -math.Sqrt(SquaredDistance(p, q))
+	}
+
+	// the mean of a column of probabilities is always 1/4
+	covariance, varianceP, varianceQ := 0.0, 0.0, 0.0
+	for nuc := range p {
+		covariance += (p[nuc] - 0.25) * (q[nuc] - 0.25)
+		varianceP += (p[nuc] - 0.25) * (p[nuc] - 0.25)
+		varianceQ += (q[nuc] - 0.25) * (q[nuc] - 0.25)
+	}
+	if varianceP == 0 || varianceQ == 0 {
+		return 0
+	}
+	return covariance / math.Sqrt(varianceP*varianceQ)
+}
+
+// CompareOptions configures the comparison of motifs.
+type CompareOptions struct {
+	Similarity ColumnSimilarity
+	// MinOverlap is the fewest columns two motifs are aligned over, or the length of the shorter motif.
+	MinOverlap int
+}
+
+// DefaultCompareOptions compares motifs by Pearson correlation over at least 5 columns.
+func DefaultCompareOptions() CompareOptions {
+	return CompareOptions{Similarity: PearsonSimilarity, MinOverlap: 5}
+}
+
+// MotifAlignment is the best way to lay one motif over another.
+type MotifAlignment struct {
+	// Offset is the column of the first motif under the first column of the second one, negative when the second one starts first.
+	Offset int
+	// Strand is Reverse when the reverse complement of the second motif is aligned.
+	Strand  Strand
+	Overlap int
+	// Score is the sum of the similarities of the overlapping columns.
+	Score float64
+	// PValue is the probability of an alignment as good for a motif of unrelated columns,
+	// corrected for the number of alignments tried.
+	PValue float64
+}
+
+/*
+   Tomtom(Query, Target, Null columns)
+       for each column i of Query
+           Null(i) ← scores of column i against every null column
+       for each strand of Target, and each offset with enough overlap
+           Score ← sum of the scores of the overlapping columns
+           p ← P(sum of Null(i) over the overlapping columns i ≥ Score), by dynamic programming
+       return the alignment with the lowest p, corrected for the number of alignments
+*/
+
+// MotifComparer compares motifs the way Tomtom does, with p-values from the scores of
+// the columns of a query motif against the columns of a whole collection of motifs.
+type MotifComparer struct {
+	opts CompareOptions
+	null [][]float64
+}
+
+// NewMotifComparer compares motifs against the columns of collection on both strands.
+func NewMotifComparer(collection []ProfileMatrix, opts CompareOptions) *MotifComparer {
+	c := &MotifComparer{opts: opts}
+	for _, profile := range collection {
+		c.null = append(c.null, profileColumns(profile)...)
+		c.null = append(c.null, profileColumns(reverseComplementProfile(profile))...)
+	}
+	return c
+}
+
+// CompareProfiles aligns b to a, with a and b as the only collection for the p-value.
+func CompareProfiles(a, b ProfileMatrix, opts CompareOptions) MotifAlignment {
+	return NewMotifComparer([]ProfileMatrix{a, b}, opts).Compare(a, b)
+}
+
+// Compare finds the alignment of b to a with the lowest p-value, the best score on ties.
+func (c *MotifComparer) Compare(a, b ProfileMatrix) MotifAlignment {
+	return c.newQuery(a).compare(b)
+}
+
+// motifQuery holds what the comparisons of one motif against others share.
+type motifQuery struct {
+	similarity ColumnSimilarity
+	minOverlap int
+	columns    [][]float64
+	low, width float64
+	// tails[start][n][s] is the probability that null columns score at least s bins
+	// summed over the n+1 columns from start
+	tails [][][]float64
+}
+
+func (c *MotifComparer) newQuery(a ProfileMatrix) *motifQuery {
+	q := &motifQuery{
+		similarity: c.opts.Similarity,
+		minOverlap: Max(Min(c.opts.MinOverlap, a.K()), 1),
+		columns:    profileColumns(a),
+	}
+
+	scores := make([][]float64, len(q.columns))
+	q.low, q.width = math.Inf(1), 0
+	high := math.Inf(-1)
+	for i, column := range q.columns {
+		scores[i] = make([]float64, len(c.null))
+		for j, null := range c.null {
+			scores[i][j] = q.similarity.Score(column, null)
+			q.low, high = math.Min(q.low, scores[i][j]), math.Max(high, scores[i][j])
+		}
+	}
+	if len(c.null) == 0 {
+		q.low, high = 0, 0
+	}
+	q.width = (high - q.low) / compareBins
+
+	// the distribution of the binned score of each column against a random null column
+	histograms := make([][]float64, len(q.columns))
+	for i := range histograms {
+		histograms[i] = make([]float64, compareBins+1)
+		for _, score := range scores[i] {
+			histograms[i][q.bin(score)] += 1 / float64(len(scores[i]))
+		}
+	}
+
+	q.tails = make([][][]float64, len(q.columns))
+	for start := range q.columns {
+		distribution := []float64{1}
+		for end := start; end < len(q.columns); end++ {
+			distribution = convolve(distribution, histograms[end])
+			tail := make([]float64, len(distribution)+1)
+			for s := len(distribution) - 1; s >= 0; s-- {
+				tail[s] = tail[s+1] + distribution[s]
+			}
+			q.tails[start] = append(q.tails[start], tail)
+		}
+	}
+	return q
+}
+
+// convolve is the distribution of the sum of two independent integer scores.
+func convolve(a, b []float64) []float64 {
+	sum := make([]float64, len(a)+len(b)-1)
+	for i, p := range a {
+		if p == 0 {
+			continue
+		}
+		for j, q := range b {
+			sum[i+j] += p * q
+		}
+	}
+	return sum
+}
+
+// bin rounds a column score to its bin, from 0 to compareBins.
+func (q *motifQuery) bin(score float64) int {
+	if q.width == 0 {
+		return 0
+	}
+	return Max(Min(int(math.Round((score-q.low)/q.width)), compareBins), 0)
+}
+
+func (q *motifQuery) compare(b ProfileMatrix) MotifAlignment {
+	ka, kb := len(q.columns), b.K()
+	minOverlap := Max(Min(q.minOverlap, kb), 1)
+	best := MotifAlignment{PValue: math.Inf(1)}
+	alignments := 0
+
+	for _, strand := range []Strand{Forward, Reverse} {
+		target := b
+		if strand == Reverse {
+			target = reverseComplementProfile(b)
+		}
+		columns := profileColumns(target)
+
+		for offset := minOverlap - kb; offset <= ka-minOverlap; offset++ {
+			start, end := Max(offset, 0), Min(ka, offset+kb)
+			score, bins := 0.0, 0
+			for i := start; i < end; i++ {
+				s := q.similarity.Score(q.columns[i], columns[i-offset])
+				score += s
+				bins += q.bin(s)
+			}
+
+			tail := q.tails[start][end-start-1]
+			p := 0.0
+			if bins < len(tail) {
+				p = tail[bins]
+			}
+			alignments++
+			if p < best.PValue || p == best.PValue && score > best.Score {
+				best = MotifAlignment{Offset: offset, Strand: strand, Overlap: end - start, Score: score, PValue: p}
+			}
+		}
+	}
+
+	// Šidák correction for the alignments tried
+	best.PValue = -math.Expm1(float64(alignments) * math.Log1p(-math.Min(best.PValue, 1)))
+	return best
+}
+
+// profileColumns are the probabilities of the 4 bases at each position of profile.
+func profileColumns(profile ProfileMatrix) [][]float64 {
+	columns := make([][]float64, profile.K())
+	for pos := range columns {
+		columns[pos] = make([]float64, 4)
+		for nuc := range columns[pos] {
+			columns[pos][nuc] = profile.Get(nuc, pos)
+		}
+	}
+	return columns
+}
+
+// reverseComplementProfile is profile as it reads on the other strand.
+func reverseComplementProfile(profile ProfileMatrix) ProfileMatrix {
+	k := profile.K()
+	data := make([][]float64, 4)
+	for nuc := range data {
+		data[nuc] = make([]float64, k)
+		for pos := range data[nuc] {
+			data[nuc][pos] = profile.Get(3-nuc, k-1-pos)
+		}
+	}
+	return ProfileMatrix{data: data}
+}
+
+// MotifFamily is a group of similar motifs.
+type MotifFamily struct {
+	// Members are the indexes of the motifs in the family, the representative first.
+	Members []int
+	// Alignments align each member to the representative.
+	Alignments []MotifAlignment
+}
+
+/*
+   ClusterMotifs(Motifs)
+       Distance(i, j) ← the larger p-value of comparing motif i to j and j to i
+       cluster the motifs by average linkage, up to the highest p-value
+       the representative of a family is the motif closest on average to the others
+*/
+
+// ClusterMotifs groups profiles into families of motifs whose alignments have p-values of at most maxPValue
+// on average, using the profiles as the collection for p-values. Families are sorted largest first.
+func ClusterMotifs(profiles []ProfileMatrix, opts CompareOptions, maxPValue float64) []MotifFamily {
+	comparer := NewMotifComparer(profiles, opts)
+	queries := make([]*motifQuery, len(profiles))
+	for i := range profiles {
+		queries[i] = comparer.newQuery(profiles[i])
+	}
+
+	distances := make([][]float64, len(profiles))
+	for i := range distances {
+		distances[i] = make([]float64, len(profiles))
+	}
+	for i := range profiles {
+		for j := i + 1; j < len(profiles); j++ {
+			d := math.Max(queries[i].compare(profiles[j]).PValue, queries[j].compare(profiles[i]).PValue)
+			distances[i][j], distances[j][i] = d, d
+		}
+	}
+
+	families := []MotifFamily{}
+	for i, cluster := range AverageLinkage(distances).ClustersBelow(maxPValue) {
+		for len(families) <= cluster {
+			families = append(families, MotifFamily{Members: []int{}})
+		}
+		families[cluster].Members = append(families[cluster].Members, i)
+	}
+
+	for f := range families {
+		family := &families[f]
+		representative, bestSum := 0, math.Inf(1)
+		for m, i := range family.Members {
+			sum := 0.0
+			for _, j := range family.Members {
+				sum += distances[i][j]
+			}
+			if sum < bestSum {
+				representative, bestSum = m, sum
+			}
+		}
+		family.Members[0], family.Members[representative] = family.Members[representative], family.Members[0]
+
+		for _, i := range family.Members {
+			family.Alignments = append(family.Alignments, queries[family.Members[0]].compare(profiles[i]))
+		}
+	}
+
+	sort.SliceStable(families, func(i, j int) bool {
+		return len(families[i].Members) > len(families[j].Members)
+	})
+	return families
+}
+
+// WriteMotifFamilies writes one line per motif with its family, its representative and its alignment to it.
+func WriteMotifFamilies(w io.Writer, motifs []Motif, families []MotifFamily) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "family\tmotif\trepresentative\toffset\tstrand\tp_value")
+	for f, family := range families {
+		representative := motifName(motifs, family.Members[0])
+		for m, i := range family.Members {
+			alignment := family.Alignments[m]
+			fmt.Fprintf(out, "%d\t%v\t%v\t%d\t%v\t%.3g\n", f+1, motifName(motifs, i), representative,
+				alignment.Offset, alignment.Strand, alignment.PValue)
+		}
+	}
+	return out.Flush()
+}
+
+// compareCommand clusters the motifs of a motif file into families, and writes them as TSV to stdout.
+//
+//	compare [-similarity pearson] [-min-overlap 5] [-p 0.01] motifs.meme
+func compareCommand(args []string) error {
+	opts := DefaultCompareOptions()
+	flags := flag.NewFlagSet("compare", flag.ContinueOnError)
+	similarity := flags.String("similarity", opts.Similarity.String(), "column similarity: pearson, kl or euclidean")
+	flags.IntVar(&opts.MinOverlap, "min-overlap", opts.MinOverlap, "fewest columns motifs are aligned over")
+	maxPValue := flags.Float64("p", 0.01, "highest average p-value of the motifs of a family")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: compare [flags] motif-file")
+	}
+	var err error
+	if opts.Similarity, err = ParseColumnSimilarity(*similarity); err != nil {
+		return err
+	}
+
+	motifs, err := ReadMotifFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	profiles := make([]ProfileMatrix, len(motifs))
+	for i := range motifs {
+		if profiles[i], err = motifs[i].Profile(0.25); err != nil {
+			return fmt.Errorf("motif %v: %v", motifName(motifs, i), err)
+		}
+	}
+
+	return WriteMotifFamilies(os.Stdout, motifs, ClusterMotifs(profiles, opts, *maxPValue))
+}
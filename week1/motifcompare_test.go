@@ -0,0 +1,106 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sitesProfile(t *testing.T, sites ...string) ProfileMatrix {
+	profile, err := NewProfileFromMotifs(NormalizeListDNA(sites), 0.25)
+	assert.NoError(t, err)
+	return profile
+}
+
+func TestColumnSimilarity(t *testing.T) {
+	a := []float64{0.7, 0.1, 0.1, 0.1}
+	b := []float64{0.1, 0.1, 0.1, 0.7}
+	uniform := []float64{0.25, 0.25, 0.25, 0.25}
+
+	assert.InDelta(t, 1, PearsonSimilarity.Score(a, a), 1e-12)
+	assert.InDelta(t, -1.0/3, PearsonSimilarity.Score(a, b), 1e-12)
+	assert.Equal(t, 0.0, PearsonSimilarity.Score(a, uniform))
+
+	assert.InDelta(t, 0, KLSimilarity.Score(a, a), 1e-12)
+	assert.InDelta(t, -0.6*math.Log2(7), KLSimilarity.Score(a, b), 1e-12)
+	assert.InDelta(t, KLSimilarity.Score(b, a), KLSimilarity.Score(a, b), 1e-12)
+
+	assert.InDelta(t, 0, EuclideanSimilarity.Score(a, a), 1e-12)
+	assert.InDelta(t, -0.6*math.Sqrt2, EuclideanSimilarity.Score(a, b), 1e-12)
+
+	for _, s := range []ColumnSimilarity{PearsonSimilarity, KLSimilarity, EuclideanSimilarity} {
+		parsed, err := ParseColumnSimilarity(s.String())
+		assert.NoError(t, err)
+		assert.Equal(t, s, parsed)
+	}
+	_, err := ParseColumnSimilarity("sandelin")
+	assert.Error(t, err)
+}
+
+func TestCompareProfiles(t *testing.T) {
+	dnaA := sitesProfile(t, "TTATCCACA", "TTATCCAAA", "TTTTCCACA", "GTATCAACA")
+	inner := sitesProfile(t, "ATCCACA", "ATCCAAA", "TTCCACA")
+	innerReverse := sitesProfile(t, "TGTGGAT", "TTTGGAT", "TGTGGAA")
+	other := sitesProfile(t, "GGCGCGC", "GGCGCGG", "GCCGCGC")
+
+	for _, similarity := range []ColumnSimilarity{PearsonSimilarity, KLSimilarity, EuclideanSimilarity} {
+		opts := CompareOptions{Similarity: similarity, MinOverlap: 5}
+		comparer := NewMotifComparer([]ProfileMatrix{dnaA, inner, innerReverse, other}, opts)
+
+		alignment := comparer.Compare(dnaA, inner)
+		assert.Equal(t, 2, alignment.Offset, similarity.String())
+		assert.Equal(t, Forward, alignment.Strand, similarity.String())
+		assert.Equal(t, 7, alignment.Overlap, similarity.String())
+
+		reverse := comparer.Compare(dnaA, innerReverse)
+		assert.Equal(t, 2, reverse.Offset, similarity.String())
+		assert.Equal(t, Reverse, reverse.Strand, similarity.String())
+		assert.InDelta(t, alignment.Score, reverse.Score, 1e-9, similarity.String())
+
+		unrelated := comparer.Compare(dnaA, other)
+		assert.True(t, alignment.PValue < 0.01, "%v: p-value %v", similarity, alignment.PValue)
+		assert.True(t, unrelated.PValue > 0.05, "%v: p-value %v", similarity, unrelated.PValue)
+	}
+}
+
+func TestCompareProfilesShortMotif(t *testing.T) {
+	a := sitesProfile(t, "ACGTTGCA")
+	b := sitesProfile(t, "TTG")
+
+	alignment := CompareProfiles(a, b, DefaultCompareOptions())
+	assert.Equal(t, 3, alignment.Overlap)
+	assert.True(t, alignment.PValue >= 0 && alignment.PValue <= 1)
+	// TTG at 3, or CAA on the other strand at 5
+	assert.Contains(t, []int{3, 5}, alignment.Offset)
+}
+
+func TestClusterMotifs(t *testing.T) {
+	profiles := []ProfileMatrix{
+		sitesProfile(t, "TTATCCACA", "TTATCCAAA", "TTTTCCACA"),
+		sitesProfile(t, "GGCGCGCCAT", "GGCGCGCCAA", "GGCCCGCCAT"),
+		sitesProfile(t, "TGTGGATAA", "TTTGGATAA", "TGTGGATAA"),
+		sitesProfile(t, "CGCGCCATG", "CGCGCCATG", "CGCGCCTTG"),
+		sitesProfile(t, "GTTATCCACAG", "GTTATCCACAG", "CTTATCCAAAG"),
+		sitesProfile(t, "AAACCCTTTGGG"),
+	}
+
+	families := ClusterMotifs(profiles, DefaultCompareOptions(), 0.01)
+	assert.Len(t, families, 3)
+	assert.ElementsMatch(t, []int{0, 2, 4}, families[0].Members)
+	assert.ElementsMatch(t, []int{1, 3}, families[1].Members)
+	assert.Equal(t, []int{5}, families[2].Members)
+
+	for _, family := range families {
+		assert.Len(t, family.Alignments, len(family.Members))
+		assert.Equal(t, Forward, family.Alignments[0].Strand)
+		assert.Equal(t, 0, family.Alignments[0].Offset)
+	}
+	// the reverse complement of the DnaA box joins it on the other strand
+	strands := map[int]Strand{}
+	for m, i := range families[0].Members {
+		strands[i] = families[0].Alignments[m].Strand
+	}
+	assert.NotEqual(t, strands[0], strands[2])
+	assert.Equal(t, strands[0], strands[4])
+}
@@ -0,0 +1,633 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// memeDefaultSites is the number of sites MEME assumes when a letter-probability matrix has no nsites.
+const memeDefaultSites = 20
+
+// Motif is a named count matrix, as kept in motif databases.
+// Counts are indexed [base][position] like the data of ProfileMatrix,
+// and may be fractional when they were made from probabilities.
+type Motif struct {
+	ID     string
+	Name   string
+	Counts [][]float64
+}
+
+// NewMotifFromSites counts the bases of aligned sites.
+func NewMotifFromSites(id string, sites sequences) (Motif, error) {
+	if err := validateMotifs(sites); err != nil {
+		return Motif{}, err
+	}
+	counts := Count(sites)
+	motif := Motif{ID: id, Counts: make([][]float64, 4)}
+	for nuc := range counts {
+		motif.Counts[nuc] = make([]float64, len(counts[nuc]))
+		for pos, count := range counts[nuc] {
+			motif.Counts[nuc][pos] = float64(count)
+		}
+	}
+	return motif, nil
+}
+
+// NewMotifFromProfile turns the probabilities of profile into the counts of that many sites.
+func NewMotifFromProfile(id string, profile ProfileMatrix, sites int) Motif {
+	motif := Motif{ID: id, Counts: make([][]float64, 4)}
+	for nuc := range motif.Counts {
+		motif.Counts[nuc] = make([]float64, profile.K())
+		for pos := range motif.Counts[nuc] {
+			motif.Counts[nuc][pos] = profile.Get(nuc, pos) * float64(sites)
+		}
+	}
+	return motif
+}
+
+// K is the length of the motif.
+func (m *Motif) K() int {
+	if len(m.Counts) == 0 {
+		return 0
+	}
+	return len(m.Counts[0])
+}
+
+// Sites is the number of sites counted at the first position.
+func (m *Motif) Sites() float64 {
+	sites := 0.0
+	for _, row := range m.Counts {
+		if len(row) > 0 {
+			sites += row[0]
+		}
+	}
+	return sites
+}
+
+// Profile is the frequency of each base at each position, with pseudocount added to every count.
+// Each column is divided by its own total, as databases do not always count the same sites at every position.
+func (m *Motif) Profile(pseudocount float64) (ProfileMatrix, error) {
+	if err := m.Validate(); err != nil {
+		return ProfileMatrix{}, err
+	}
+	if pseudocount < 0 {
+		return ProfileMatrix{}, fmt.Errorf("pseudocount must be at least 0, got %v", pseudocount)
+	}
+
+	data := make([][]float64, 4)
+	for nuc := range data {
+		data[nuc] = make([]float64, m.K())
+	}
+	for pos := 0; pos < m.K(); pos++ {
+		total := 4 * pseudocount
+		for nuc := range m.Counts {
+			total += m.Counts[nuc][pos]
+		}
+		for nuc := range m.Counts {
+			data[nuc][pos] = (m.Counts[nuc][pos] + pseudocount) / total
+		}
+	}
+	return NewProfileMatrix(data)
+}
+
+// Validate checks that the motif has 4 rows of the same length, with counts of at least 0 and no empty column.
+func (m *Motif) Validate() error {
+	if len(m.Counts) != 4 {
+		return fmt.Errorf("motif must have 4 rows, got %v", len(m.Counts))
+	}
+	k := m.K()
+	if k == 0 {
+		return errors.New("motif has no columns")
+	}
+	for nuc, row := range m.Counts {
+		if len(row) != k {
+			return fmt.Errorf("row %c has %v columns, not %v", indexToLetter[nuc], len(row), k)
+		}
+	}
+	for pos := 0; pos < k; pos++ {
+		total := 0.0
+		for nuc := range m.Counts {
+			count := m.Counts[nuc][pos]
+			if count < 0 || math.IsNaN(count) || math.IsInf(count, 0) {
+				return fmt.Errorf("column %v has count %v", pos+1, count)
+			}
+			total += count
+		}
+		if total == 0 {
+			return fmt.Errorf("column %v has no counts", pos+1)
+		}
+	}
+	return nil
+}
+
+// MotifFormat is a file format of motif databases.
+type MotifFormat int
+
+const (
+	// JasparFormat is the JASPAR format, with a ">ID name" header and one "A [ counts ]" row per base.
+	// The rows of .pfm files, without header and letters, are read too.
+	JasparFormat MotifFormat = iota
+	// MEMEFormat is the MEME minimal motif format, with a letter-probability matrix of one row per position.
+	MEMEFormat
+	// TransfacFormat is the TRANSFAC matrix format, with a P0 line and one row of counts per position.
+	TransfacFormat
+)
+
+func (f MotifFormat) String() string {
+	switch f {
+	case MEMEFormat:
+		return "meme"
+	case TransfacFormat:
+		return "transfac"
+	}
+	return "jaspar"
+}
+
+// ParseMotifFormat is the format called name, as printed by String.
+func ParseMotifFormat(name string) (MotifFormat, error) {
+	switch strings.ToLower(name) {
+	case "jaspar", "pfm":
+		return JasparFormat, nil
+	case "meme":
+		return MEMEFormat, nil
+	case "transfac":
+		return TransfacFormat, nil
+	}
+	return JasparFormat, fmt.Errorf("unknown motif format %q", name)
+}
+
+// DetectMotifFormat guesses the format of a motif file from its content.
+func DetectMotifFormat(data []byte) MotifFormat {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "MEME", "MOTIF":
+			return MEMEFormat
+		case "AC", "ID", "P0", "PO", "XX", "//":
+			return TransfacFormat
+		}
+		if strings.HasPrefix(fields[0], ">") || len(fields[0]) == 1 {
+			return JasparFormat
+		}
+	}
+	return JasparFormat
+}
+
+// ReadMotifFile reads every motif of a file in any of the motif formats.
+func ReadMotifFile(filename string) ([]Motif, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ReadMotifs(bytes.NewReader(data), DetectMotifFormat(data))
+}
+
+// readMotif reads the motif with the ID from a motif file, or the first motif when id is empty.
+func readMotif(filename, id string) (Motif, error) {
+	motifs, err := ReadMotifFile(filename)
+	if err != nil {
+		return Motif{}, err
+	}
+	for _, motif := range motifs {
+		if id == "" || motif.ID == id {
+			return motif, nil
+		}
+	}
+	if id == "" {
+		return Motif{}, fmt.Errorf("no motifs in %v", filename)
+	}
+	return Motif{}, fmt.Errorf("no motif %v in %v", id, filename)
+}
+
+// ReadMotifs reads every motif of r in format.
+func ReadMotifs(r io.Reader, format MotifFormat) ([]Motif, error) {
+	var motifs []Motif
+	var err error
+	switch format {
+	case MEMEFormat:
+		motifs, err = ReadMEME(r)
+	case TransfacFormat:
+		motifs, err = ReadTransfac(r)
+	default:
+		motifs, err = ReadJaspar(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range motifs {
+		if err := motifs[i].Validate(); err != nil {
+			return nil, fmt.Errorf("motif %v: %v", motifName(motifs, i), err)
+		}
+	}
+	return motifs, nil
+}
+
+// WriteMotifs writes motifs to w in format.
+func WriteMotifs(w io.Writer, motifs []Motif, format MotifFormat) error {
+	switch format {
+	case MEMEFormat:
+		return WriteMEME(w, motifs)
+	case TransfacFormat:
+		return WriteTransfac(w, motifs)
+	}
+	return WriteJaspar(w, motifs)
+}
+
+// motifName names motif i in error messages, by its ID or by its number.
+func motifName(motifs []Motif, i int) string {
+	if motifs[i].ID != "" {
+		return motifs[i].ID
+	}
+	return strconv.Itoa(i + 1)
+}
+
+// motifLines calls parse on every line of r with the line number, stopping at the first error.
+func motifLines(r io.Reader, parse func(text string) error) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if err := parse(strings.TrimRight(scanner.Text(), "\r")); err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// parseCounts parses every field as a number.
+func parseCounts(fields []string) ([]float64, error) {
+	counts := make([]float64, len(fields))
+	for i, field := range fields {
+		count, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", field)
+		}
+		counts[i] = count
+	}
+	return counts, nil
+}
+
+// formatCount writes whole counts without decimals, and others with at most 3.
+func formatCount(count float64) string {
+	return strconv.FormatFloat(math.Round(count*1000)/1000, 'f', -1, 64)
+}
+
+/*
+   JASPAR
+       >MA0001.1 AGL3
+       A  [  0  3 79 40 66 48 65 11 65  0 ]
+       C  [ 94 75  4  3  1  2  5  2  3  3 ]
+       G  [  1  0  3  4  1  0  5  3 28 88 ]
+       T  [  2 19 11 50 29 47 22 81  1  6 ]
+*/
+
+// ReadJaspar reads motifs in JASPAR format. A file of 4 rows of counts without header is one motif without ID.
+func ReadJaspar(r io.Reader) ([]Motif, error) {
+	motifs := []Motif{}
+	err := motifLines(r, func(text string) error {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil
+		}
+		if strings.HasPrefix(text, ">") {
+			fields := strings.Fields(text[1:])
+			motif := Motif{Counts: [][]float64{}}
+			if len(fields) > 0 {
+				motif.ID = fields[0]
+				motif.Name = strings.Join(fields[1:], " ")
+			}
+			motifs = append(motifs, motif)
+			return nil
+		}
+
+		if len(motifs) == 0 || len(motifs[len(motifs)-1].Counts) == 4 {
+			motifs = append(motifs, Motif{Counts: [][]float64{}})
+		}
+		motif := &motifs[len(motifs)-1]
+		letter := indexToLetter[len(motif.Counts)]
+		if c := text[0]; c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' {
+			if c&^0x20 != letter {
+				return fmt.Errorf("row %c where row %c was expected", c, letter)
+			}
+			text = text[1:]
+		}
+		text = strings.NewReplacer("[", " ", "]", " ").Replace(text)
+		row, err := parseCounts(strings.Fields(text))
+		if err != nil {
+			return err
+		}
+		motif.Counts = append(motif.Counts, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return motifs, nil
+}
+
+// WriteJaspar writes motifs in JASPAR format.
+func WriteJaspar(w io.Writer, motifs []Motif) error {
+	out := bufio.NewWriter(w)
+	for _, motif := range motifs {
+		fmt.Fprintf(out, ">%v", motif.ID)
+		if motif.Name != "" {
+			fmt.Fprintf(out, " %v", motif.Name)
+		}
+		fmt.Fprintln(out)
+		for nuc, row := range motif.Counts {
+			fmt.Fprintf(out, "%c  [", indexToLetter[nuc])
+			for _, count := range row {
+				fmt.Fprintf(out, " %6s", formatCount(count))
+			}
+			fmt.Fprintln(out, " ]")
+		}
+	}
+	return out.Flush()
+}
+
+/*
+   MEME minimal motif format
+       MEME version 4
+
+       ALPHABET= ACGT
+
+       MOTIF crp
+       letter-probability matrix: alength= 4 w= 19 nsites= 17 E= 4.1e-009
+        0.000000  0.176471  0.000000  0.823529
+        ...
+*/
+
+// ReadMEME reads motifs in MEME minimal motif format. The probabilities are turned into counts of nsites sites,
+// 20 when the matrix does not give nsites.
+func ReadMEME(r io.Reader) ([]Motif, error) {
+	motifs := []Motif{}
+	// rows of the matrix still to read, -1 outside a matrix
+	rows, sites := -1, 0.0
+	err := motifLines(r, func(text string) error {
+		fields := strings.Fields(text)
+		if rows > 0 {
+			if len(fields) != 4 {
+				return fmt.Errorf("matrix row has %v probabilities, not 4", len(fields))
+			}
+			probs, err := parseCounts(fields)
+			if err != nil {
+				return err
+			}
+			motif := &motifs[len(motifs)-1]
+			for nuc, p := range probs {
+				motif.Counts[nuc] = append(motif.Counts[nuc], p*sites)
+			}
+			rows--
+			return nil
+		}
+		if len(fields) == 0 {
+			return nil
+		}
+
+		switch {
+		case strings.HasPrefix(fields[0], "ALPHABET"):
+			alphabet := strings.TrimSpace(strings.TrimPrefix(text, "ALPHABET="))
+			if !strings.HasPrefix(alphabet, "ACGT") {
+				return fmt.Errorf("alphabet %q is not ACGT", alphabet)
+			}
+		case fields[0] == "MOTIF":
+			if len(fields) < 2 {
+				return errors.New("MOTIF without ID")
+			}
+			motifs = append(motifs, Motif{ID: fields[1], Name: strings.Join(fields[2:], " "), Counts: make([][]float64, 4)})
+		case fields[0] == "letter-probability":
+			if len(motifs) == 0 {
+				return errors.New("matrix before the first MOTIF")
+			}
+			var err error
+			if rows, sites, err = parseMEMEMatrixHeader(fields); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rows > 0 {
+		return nil, fmt.Errorf("motif %v: %v matrix rows missing", motifName(motifs, len(motifs)-1), rows)
+	}
+	return motifs, nil
+}
+
+// parseMEMEMatrixHeader reads the width and number of sites of a letter-probability matrix.
+func parseMEMEMatrixHeader(fields []string) (int, float64, error) {
+	width, sites := -1, float64(memeDefaultSites)
+	for i := 0; i+1 < len(fields); i++ {
+		key := fields[i]
+		value := fields[i+1]
+		// "w=19" as well as "w= 19"
+		if j := strings.Index(key, "="); j >= 0 && j < len(key)-1 {
+			key, value = key[:j+1], key[j+1:]
+		}
+		switch key {
+		case "alength=":
+			if value != "4" {
+				return 0, 0, fmt.Errorf("alength is %v, not 4", value)
+			}
+		case "w=":
+			w, err := strconv.Atoi(value)
+			if err != nil || w <= 0 {
+				return 0, 0, fmt.Errorf("invalid width %q", value)
+			}
+			width = w
+		case "nsites=":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil || n <= 0 {
+				return 0, 0, fmt.Errorf("invalid nsites %q", value)
+			}
+			sites = n
+		}
+	}
+	if width < 0 {
+		return 0, 0, errors.New("letter-probability matrix without w=")
+	}
+	return width, sites, nil
+}
+
+// WriteMEME writes motifs in MEME minimal motif format, with a uniform background.
+func WriteMEME(w io.Writer, motifs []Motif) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprint(out, "MEME version 4\n\nALPHABET= ACGT\n\nstrands: + -\n\n")
+	fmt.Fprint(out, "Background letter frequencies\nA 0.25 C 0.25 G 0.25 T 0.25\n")
+	for i := range motifs {
+		motif := &motifs[i]
+		profile, err := motif.Profile(0)
+		if err != nil {
+			return fmt.Errorf("motif %v: %v", motifName(motifs, i), err)
+		}
+		fmt.Fprintf(out, "\nMOTIF %v", motif.ID)
+		if motif.Name != "" {
+			fmt.Fprintf(out, " %v", motif.Name)
+		}
+		fmt.Fprintf(out, "\nletter-probability matrix: alength= 4 w= %d nsites= %v\n", motif.K(), formatCount(motif.Sites()))
+		for pos := 0; pos < motif.K(); pos++ {
+			for nuc := 0; nuc < 4; nuc++ {
+				fmt.Fprintf(out, " %.6f", profile.Get(nuc, pos))
+			}
+			fmt.Fprintln(out)
+		}
+	}
+	return out.Flush()
+}
+
+/*
+   TRANSFAC
+       ID  V$MYOD_01
+       XX
+       P0      A      C      G      T
+       01      1      2      2      0      S
+       ...
+       XX
+       //
+*/
+
+// ReadTransfac reads motifs in TRANSFAC format. The ID is taken from the ID line, or the AC line without one,
+// and the name from the NA line, or the DE line without one.
+func ReadTransfac(r io.Reader) ([]Motif, error) {
+	motifs := []Motif{}
+	var current *Motif
+	var accession, description string
+	// the base of each column of the matrix, nil outside a matrix
+	var columns []int
+
+	finish := func() {
+		if current != nil {
+			if current.ID == "" {
+				current.ID = accession
+			}
+			if current.Name == "" {
+				current.Name = description
+			}
+			motifs = append(motifs, *current)
+		}
+		current, accession, description, columns = nil, "", "", nil
+	}
+	start := func() {
+		if current == nil {
+			current = &Motif{}
+		}
+	}
+
+	err := motifLines(r, func(text string) error {
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			return nil
+		}
+		code := fields[0]
+		value := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), code))
+
+		switch code {
+		case "//":
+			finish()
+		case "XX":
+			columns = nil
+		case "AC":
+			start()
+			accession = value
+		case "ID":
+			start()
+			current.ID = value
+		case "NA":
+			start()
+			current.Name = value
+		case "DE":
+			start()
+			description = value
+		case "P0", "PO":
+			start()
+			if current.Counts != nil {
+				return errors.New("second matrix in a motif")
+			}
+			var err error
+			if columns, err = transfacColumns(fields[1:]); err != nil {
+				return err
+			}
+			current.Counts = make([][]float64, 4)
+		default:
+			if columns == nil {
+				return nil
+			}
+			if _, err := strconv.Atoi(code); err != nil {
+				return fmt.Errorf("matrix row %q does not start with its position", code)
+			}
+			if len(fields) < len(columns)+1 {
+				return fmt.Errorf("matrix row has %v counts, not %v", len(fields)-1, len(columns))
+			}
+			counts, err := parseCounts(fields[1 : len(columns)+1])
+			if err != nil {
+				return err
+			}
+			for i, count := range counts {
+				current.Counts[columns[i]] = append(current.Counts[columns[i]], count)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	finish()
+	return motifs, nil
+}
+
+// transfacColumns is the base of each column of a P0 line, which must name A, C, G and T once each.
+func transfacColumns(letters []string) ([]int, error) {
+	columns := make([]int, len(letters))
+	seen := map[int]bool{}
+	for i, letter := range letters {
+		nuc := strings.Index("ACGT", strings.ToUpper(letter))
+		if len(letter) != 1 || nuc < 0 || seen[nuc] {
+			return nil, fmt.Errorf("P0 line must name A, C, G and T once, got %v", strings.Join(letters, " "))
+		}
+		columns[i] = nuc
+		seen[nuc] = true
+	}
+	if len(columns) != 4 {
+		return nil, fmt.Errorf("P0 line must name A, C, G and T once, got %v", strings.Join(letters, " "))
+	}
+	return columns, nil
+}
+
+// WriteTransfac writes motifs in TRANSFAC format, with the most common base at the end of each row.
+func WriteTransfac(w io.Writer, motifs []Motif) error {
+	out := bufio.NewWriter(w)
+	for i := range motifs {
+		motif := &motifs[i]
+		if err := motif.Validate(); err != nil {
+			return fmt.Errorf("motif %v: %v", motifName(motifs, i), err)
+		}
+		fmt.Fprintf(out, "ID  %v\nXX\n", motif.ID)
+		if motif.Name != "" {
+			fmt.Fprintf(out, "NA  %v\nXX\n", motif.Name)
+		}
+		fmt.Fprintf(out, "P0 %8s %8s %8s %8s\n", "A", "C", "G", "T")
+		for pos := 0; pos < motif.K(); pos++ {
+			fmt.Fprintf(out, "%02d", pos+1)
+			best := 0
+			for nuc := 0; nuc < 4; nuc++ {
+				fmt.Fprintf(out, " %8s", formatCount(motif.Counts[nuc][pos]))
+				if motif.Counts[nuc][pos] > motif.Counts[best][pos] {
+					best = nuc
+				}
+			}
+			fmt.Fprintf(out, "      %c\n", indexToLetter[best])
+		}
+		fmt.Fprint(out, "XX\n//\n")
+	}
+	return out.Flush()
+}
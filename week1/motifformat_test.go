@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const jasparAGL3 = `>MA0001.1 AGL3
+A  [  0  3 79 40 66 48 65 11 65  0 ]
+C  [ 94 75  4  3  1  2  5  2  3  3 ]
+G  [  1  0  3  4  1  0  5  3 28 88 ]
+T  [  2 19 11 50 29 47 22 81  1  6 ]
+`
+
+const memeCRP = `MEME version 4
+
+ALPHABET= ACGT
+
+strands: + -
+
+Background letter frequencies
+A 0.303 C 0.183 G 0.209 T 0.306
+
+MOTIF crp alt_name
+letter-probability matrix: alength= 4 w= 3 nsites= 10 E= 4.1e-009
+ 0.000000  0.100000  0.000000  0.900000
+ 0.500000  0.000000  0.500000  0.000000
+ 0.2  0.2  0.3  0.3
+URL http://example.org
+
+MOTIF lexA
+letter-probability matrix: alength= 4 w= 2
+ 1 0 0 0
+ 0 0 0 1
+`
+
+const transfacMyoD = `VV  TRANSFAC MATRIX TABLE
+XX
+//
+AC  M00001
+XX
+ID  V$MYOD_01
+XX
+DE  myoD
+P0      T      G      C      A
+01      0      2      2      1      S
+02      0      0      1      4      A
+XX
+//
+AC  M00002
+P0 A C G T
+01 1 2 3 4 N
+`
+
+func TestReadJaspar(t *testing.T) {
+	motifs, err := ReadJaspar(strings.NewReader(jasparAGL3))
+	assert.NoError(t, err)
+	assert.Len(t, motifs, 1)
+	assert.Equal(t, "MA0001.1", motifs[0].ID)
+	assert.Equal(t, "AGL3", motifs[0].Name)
+	assert.Equal(t, 10, motifs[0].K())
+	assert.Equal(t, 97.0, motifs[0].Sites())
+	assert.Equal(t, []float64{0, 3, 79, 40, 66, 48, 65, 11, 65, 0}, motifs[0].Counts[0])
+
+	profile, err := motifs[0].Profile(0)
+	assert.NoError(t, err)
+	assert.Equal(t, "CCATAAATAG", DeNormalizeDNA(profile.Consensus()))
+}
+
+func TestReadJasparPfm(t *testing.T) {
+	motifs, err := ReadMotifs(strings.NewReader("0 3 79\n94 75 4\n1 0 3\n2 19 11\n"), JasparFormat)
+	assert.NoError(t, err)
+	assert.Len(t, motifs, 1)
+	assert.Equal(t, "", motifs[0].ID)
+	assert.Equal(t, []float64{2, 19, 11}, motifs[0].Counts[3])
+}
+
+func TestReadJasparInvalid(t *testing.T) {
+	_, err := ReadJaspar(strings.NewReader(">M1\nA [1 2]\nG [1 2]\n"))
+	assert.EqualError(t, err, "line 3: row G where row C was expected")
+
+	_, err = ReadJaspar(strings.NewReader(">M1\nA [1 x]\n"))
+	assert.EqualError(t, err, `line 2: "x" is not a number`)
+
+	_, err = ReadMotifs(strings.NewReader(">M1\nA [1 2]\nC [1 2]\nG [1]\nT [1 2]\n"), JasparFormat)
+	assert.EqualError(t, err, "motif M1: row G has 1 columns, not 2")
+
+	_, err = ReadMotifs(strings.NewReader(">M1\nA [1 0]\nC [1 0]\nG [1 0]\nT [1 0]\n"), JasparFormat)
+	assert.EqualError(t, err, "motif M1: column 2 has no counts")
+}
+
+func TestReadMEME(t *testing.T) {
+	motifs, err := ReadMotifs(strings.NewReader(memeCRP), MEMEFormat)
+	assert.NoError(t, err)
+	assert.Len(t, motifs, 2)
+
+	assert.Equal(t, "crp", motifs[0].ID)
+	assert.Equal(t, "alt_name", motifs[0].Name)
+	assert.Equal(t, 3, motifs[0].K())
+	assert.InDelta(t, 10, motifs[0].Sites(), 1e-9)
+	assert.InDelta(t, 9, motifs[0].Counts[3][0], 1e-9)
+	assert.InDelta(t, 5, motifs[0].Counts[2][1], 1e-9)
+
+	assert.Equal(t, "lexA", motifs[1].ID)
+	assert.InDelta(t, memeDefaultSites, motifs[1].Sites(), 1e-9)
+	assert.Equal(t, []float64{0, memeDefaultSites}, motifs[1].Counts[3])
+}
+
+func TestReadMEMEInvalid(t *testing.T) {
+	_, err := ReadMEME(strings.NewReader("MEME version 4\nALPHABET= ACDEFGHIKLMNPQRSTVWY\n"))
+	assert.EqualError(t, err, `line 2: alphabet "ACDEFGHIKLMNPQRSTVWY" is not ACGT`)
+
+	_, err = ReadMEME(strings.NewReader("MOTIF m1\nletter-probability matrix: alength= 4 w= 2\n0.25 0.25 0.25 0.25\n"))
+	assert.EqualError(t, err, "motif m1: 1 matrix rows missing")
+
+	_, err = ReadMEME(strings.NewReader("MOTIF m1\nletter-probability matrix: alength= 4 w= 1\n0.5 0.5\n"))
+	assert.EqualError(t, err, "line 3: matrix row has 2 probabilities, not 4")
+
+	_, err = ReadMEME(strings.NewReader("MOTIF m1\nletter-probability matrix: alength= 4\n"))
+	assert.EqualError(t, err, "line 2: letter-probability matrix without w=")
+}
+
+func TestReadTransfac(t *testing.T) {
+	motifs, err := ReadMotifs(strings.NewReader(transfacMyoD), TransfacFormat)
+	assert.NoError(t, err)
+	assert.Len(t, motifs, 2)
+
+	assert.Equal(t, "V$MYOD_01", motifs[0].ID)
+	assert.Equal(t, "myoD", motifs[0].Name)
+	// columns in T G C A order
+	assert.Equal(t, [][]float64{{1, 4}, {2, 1}, {2, 0}, {0, 0}}, motifs[0].Counts)
+
+	// no ID line, and no closing //
+	assert.Equal(t, "M00002", motifs[1].ID)
+	assert.Equal(t, [][]float64{{1}, {2}, {3}, {4}}, motifs[1].Counts)
+}
+
+func TestReadTransfacInvalid(t *testing.T) {
+	_, err := ReadTransfac(strings.NewReader("ID  M1\nP0 A C G G\n"))
+	assert.EqualError(t, err, "line 2: P0 line must name A, C, G and T once, got A C G G")
+
+	_, err = ReadTransfac(strings.NewReader("ID  M1\nP0 A C G T\n01 1 2 3\n"))
+	assert.EqualError(t, err, "line 3: matrix row has 3 counts, not 4")
+}
+
+func TestMotifFormatsRoundTrip(t *testing.T) {
+	sites := NormalizeListDNA([]string{"TTATCCACA", "TTATCCAAA", "TTTTCCACA", "GTATCAACA"})
+	motif, err := NewMotifFromSites("dnaA", sites)
+	assert.NoError(t, err)
+	motif.Name = "DnaA box"
+
+	for _, format := range []MotifFormat{JasparFormat, MEMEFormat, TransfacFormat} {
+		var buf bytes.Buffer
+		assert.NoError(t, WriteMotifs(&buf, []Motif{motif, motif}, format))
+		assert.Equal(t, format, DetectMotifFormat(buf.Bytes()), format.String())
+
+		motifs, err := ReadMotifs(&buf, format)
+		assert.NoError(t, err, format.String())
+		assert.Len(t, motifs, 2, format.String())
+		for _, read := range motifs {
+			assert.Equal(t, motif.ID, read.ID, format.String())
+			assert.Equal(t, motif.Name, read.Name, format.String())
+			for nuc := range motif.Counts {
+				assert.InDeltaSlice(t, motif.Counts[nuc], read.Counts[nuc], 1e-5, format.String())
+			}
+		}
+	}
+}
+
+func TestNewMotifFromProfile(t *testing.T) {
+	profile, err := NewProfileMatrix([][]float64{{0.5, 0.1}, {0.5, 0.2}, {0, 0.3}, {0, 0.4}})
+	assert.NoError(t, err)
+
+	motif := NewMotifFromProfile("m", profile, 10)
+	assert.Equal(t, [][]float64{{5, 1}, {5, 2}, {0, 3}, {0, 4}}, motif.Counts)
+	assert.Equal(t, 10.0, motif.Sites())
+
+	again, err := motif.Profile(0)
+	assert.NoError(t, err)
+	for nuc := 0; nuc < 4; nuc++ {
+		for pos := 0; pos < 2; pos++ {
+			assert.InDelta(t, profile.Get(nuc, pos), again.Get(nuc, pos), 1e-9)
+		}
+	}
+
+	smoothed, err := motif.Profile(1)
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.0/14, smoothed.Get(2, 0), 1e-9)
+}
+
+func TestParseMotifFormat(t *testing.T) {
+	for _, format := range []MotifFormat{JasparFormat, MEMEFormat, TransfacFormat} {
+		parsed, err := ParseMotifFormat(format.String())
+		assert.NoError(t, err)
+		assert.Equal(t, format, parsed)
+	}
+	_, err := ParseMotifFormat("homer")
+	assert.EqualError(t, err, `unknown motif format "homer"`)
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
-	"fmt"
-	"os"
+	"bytes"
+	"math"
+	"math/bits"
+	"runtime"
+	"sort"
+	"sync/atomic"
 )
 
 /*
@@ -18,77 +22,307 @@ import (
 */
 
 // MotifEnumeration returns all motifs that are present in all sequences
-// with at most d mismatches
+// with at most d mismatches, in alphabetical order
 func MotifEnumeration(dna sequences, k, d int) sequences {
 	if len(dna) < 2 {
 		panic("must give more than 1 sequence")
 	}
-	patterns := map[string]sequence{}
+	return MotifEnumerationQuorum(dna, k, d, len(dna))
+}
 
-	firstPattern := dna[0]
-	for i := 0; i <= len(firstPattern)-k; i++ {
-		kmer := firstPattern[i : i+k]
+// MotifEnumerationQuorum returns all k-mers present with at most d mismatches in at least q of the sequences of dna,
+// in alphabetical order.
+// The d-neighborhood of each sequence is collected once as a set of k-mer indexes,
+// instead of searching every sequence for every candidate.
+func MotifEnumerationQuorum(dna sequences, k, d, q int) sequences {
+	results := sequences{}
+	if k < 1 || q > len(dna) {
+		return results
+	}
+	q = Max(q, 1)
+	size := int(Pow4(k))
 
-		neighbHood := NeighborsSimple(kmer, d)
+	// with a quorum of all sequences, the sets can be intersected, otherwise each k-mer is counted
+	var common kmerSet
+	var counts []int32
+	if q == len(dna) {
+		common = newKmerSet(size)
+	} else {
+		counts = make([]int32, size)
+	}
 
-		for n := 0; n < len(neighbHood); n++ {
-			missing := false
-			neib := neighbHood[n]
-			for j := 1; j < len(dna); j++ {
-				if !kmerExistsWithMisMatches(dna[j], neib, d) {
-					missing = true
-					break
-				}
-			}
+	for s, text := range dna {
+		neighborhood := dNeighborhood(text, k, d)
+		if counts != nil {
+			neighborhood.each(func(index int) {
+				counts[index]++
+			})
+			continue
+		}
 
-			if !missing {
-				patterns[string(neib)] = neib
-			}
+		if s == 0 {
+			common = neighborhood
+		} else {
+			common.intersect(neighborhood)
+		}
+		if common.empty() {
+			return results
 		}
 	}
 
-	results := sequences{}
-	for _, value := range patterns {
-		results = append(results, value)
+	if counts != nil {
+		common = newKmerSet(size)
+		for index, count := range counts {
+			if int(count) >= q {
+				common.add(index)
+			}
+		}
 	}
 
+	common.each(func(index int) {
+		results = append(results, NormalizeDNA(IndexToPatternStr(k, index)))
+	})
 	return results
 }
 
-func kmerExistsWithMisMatches(text sequence, kmer sequence, distance int) bool {
-	textLen := len(text)
-	patLen := len(kmer)
+// dNeighborhood is the set of k-mers with at most d mismatches to a k-mer of text.
+func dNeighborhood(text sequence, k, d int) kmerSet {
+	size := int(Pow4(k))
+	neighborhood := newKmerSet(size)
+	// a k-mer seen before has the same neighbors
+	seen := newKmerSet(size)
+	for i := 0; i <= len(text)-k; i++ {
+		kmer := text[i : i+k]
+		index := PatternToIndex(kmer)
+		if seen.has(index) {
+			continue
+		}
+		seen.add(index)
+		eachNeighborIndex(kmer, d, neighborhood.add)
+	}
+	return neighborhood
+}
+
+// eachNeighborIndex calls visit with the index of every k-mer with at most d mismatches to pattern, each once.
+func eachNeighborIndex(pattern sequence, d int, visit func(index int)) {
+	var neighbors func(pos, prefix, d int)
+	neighbors = func(pos, prefix, d int) {
+		if pos == len(pattern) {
+			visit(prefix)
+			return
+		}
+		for base := 0; base < 4; base++ {
+			if base == int(pattern[pos]) {
+				neighbors(pos+1, prefix*4+base, d)
+			} else if d > 0 {
+				neighbors(pos+1, prefix*4+base, d-1)
+			}
+		}
+	}
+	neighbors(0, 0, d)
+}
+
+// kmerSet is a set of k-mer indexes, one bit per k-mer.
+type kmerSet []uint64
+
+func newKmerSet(size int) kmerSet {
+	return make(kmerSet, (size+63)/64)
+}
+
+func (set kmerSet) add(index int) {
+	set[index/64] |= 1 << uint(index%64)
+}
+
+func (set kmerSet) has(index int) bool {
+	return set[index/64]&(1<<uint(index%64)) != 0
+}
+
+func (set kmerSet) intersect(other kmerSet) {
+	for i := range set {
+		set[i] &= other[i]
+	}
+}
 
-	for i := 0; i <= textLen-patLen; i++ {
-		window := text[i : i+patLen]
-		if HammingDistance(window, kmer) <= distance {
-			return true
+func (set kmerSet) empty() bool {
+	for _, word := range set {
+		if word != 0 {
+			return false
 		}
 	}
+	return true
+}
 
-	return false
+// each calls visit with every index in the set, in increasing order.
+func (set kmerSet) each(visit func(index int)) {
+	for i, word := range set {
+		for word != 0 {
+			bit := bits.TrailingZeros64(word)
+			visit(i*64 + bit)
+			word &= word - 1
+		}
+	}
 }
 
+/*
+   MedianString(Dna, k)
+       distance ← ∞
+       for each k-mer Pattern from AA…AA to TT…TT
+           if distance > d(Pattern, Dna)
+               distance ← d(Pattern, Dna)
+               Median ← Pattern
+       return Median
+*/
+
+// MedianString finds all k-mers with the smallest total Hamming distance to dna, sorted alphabetically.
+// Instead of trying all 4^k k-mers, it searches a tree of prefixes, and leaves out a prefix
+// when its distance to dna is already larger than the best distance found, since extending
+// a prefix never lowers its distance. The subtrees are searched in parallel.
 func MedianString(dna sequences, k int) sequences {
-	distance := k * len(dna) * len(dna[0])
-	median := -1
+	if len(dna) == 0 || k < 1 {
+		return sequences{}
+	}
+	for _, text := range dna {
+		if len(text) < k {
+			return sequences{}
+		}
+	}
+
+	// any k-mer of dna bounds the distance of the medians
+	best := int64(DistanceBetweenPatternAndStrings(dna, dna[0][:k]))
+
+	prefixLen := Min(k, 2)
+	jobs := make(chan int)
+	results := make(chan []medianCandidate)
+	workers := Min(runtime.NumCPU(), int(Pow4(prefixLen)))
+	for w := 0; w < workers; w++ {
+		go func() {
+			search := newMedianSearch(dna, k, &best)
+			for prefix := range jobs {
+				search.searchPrefix(IndexToPatternStr(prefixLen, prefix))
+			}
+			results <- search.candidates
+		}()
+	}
+	for prefix := 0; prefix < int(Pow4(prefixLen)); prefix++ {
+		jobs <- prefix
+	}
+	close(jobs)
 
-	count := int(Pow4(k))
-	for i := 0; i < count; i++ {
-		kmer := IndexToPattern(k, i)
+	candidates := []medianCandidate{}
+	for w := 0; w < workers; w++ {
+		candidates = append(candidates, <-results...)
+	}
 
-		//TODO: fix this weird bug with having to do NormalizeDNA(DeNormalizeDNA(kmer))
-		dist := DistanceBetweenPatternAndStrings(dna, NormalizeDNA(DeNormalizeDNA(kmer)))
-		fmt.Fprintf(os.Stderr, "dist=%v distance=%v kmer=%v\n", dist, distance, DeNormalizeDNA(kmer))
-		if dist < distance {
-			distance = dist
-			median = i
+	medians := sequences{}
+	for _, candidate := range candidates {
+		if int64(candidate.distance) == best {
+			medians = append(medians, candidate.pattern)
 		}
 	}
+	sort.Slice(medians, func(i, j int) bool {
+		return bytes.Compare(medians[i], medians[j]) < 0
+	})
+	return medians
+}
+
+type medianCandidate struct {
+	pattern  sequence
+	distance int
+}
 
-	return sequences{IndexToPattern(k, median)}
+// medianSearch searches the tree of prefixes for MedianString.
+type medianSearch struct {
+	dna sequences
+	k   int
+	// best is the lowest distance found by any search, shared between goroutines
+	best *int64
+	// mismatches[d][s][i] is the Hamming distance between the first d bases of pattern and dna[s][i:i+d]
+	mismatches [][][]int
+	pattern    sequence
+	candidates []medianCandidate
 }
 
+func newMedianSearch(dna sequences, k int, best *int64) *medianSearch {
+	search := &medianSearch{
+		dna:        dna,
+		k:          k,
+		best:       best,
+		mismatches: make([][][]int, k+1),
+		pattern:    make(sequence, k),
+		candidates: []medianCandidate{},
+	}
+	for d := range search.mismatches {
+		search.mismatches[d] = make([][]int, len(dna))
+		for s := range dna {
+			search.mismatches[d][s] = make([]int, len(dna[s])-k+1)
+		}
+	}
+	return search
+}
+
+// searchPrefix searches all k-mers starting with prefix.
+func (m *medianSearch) searchPrefix(prefix string) {
+	for depth, base := range NormalizeDNA(prefix) {
+		m.extend(depth, base)
+	}
+	m.visit(len(prefix))
+}
+
+// extend sets the base at depth of the pattern, and counts the mismatches of the longer prefix.
+func (m *medianSearch) extend(depth int, base byte) {
+	m.pattern[depth] = base
+	for s, text := range m.dna {
+		previous, next := m.mismatches[depth][s], m.mismatches[depth+1][s]
+		for i := range next {
+			next[i] = previous[i]
+			if text[i+depth] != base {
+				next[i]++
+			}
+		}
+	}
+}
+
+// distance is the distance between the prefix of length depth and dna, or more than limit when it is larger than limit.
+func (m *medianSearch) distance(depth int, limit int64) int64 {
+	distance := int64(0)
+	for s := range m.dna {
+		minimum := m.k
+		for _, count := range m.mismatches[depth][s] {
+			if count < minimum {
+				minimum = count
+			}
+		}
+		distance += int64(minimum)
+		if distance > limit {
+			break
+		}
+	}
+	return distance
+}
+
+func (m *medianSearch) visit(depth int) {
+	best := atomic.LoadInt64(m.best)
+	distance := m.distance(depth, best)
+	if distance > best {
+		return
+	}
+
+	if depth == m.k {
+		m.candidates = append(m.candidates, medianCandidate{append(sequence{}, m.pattern...), int(distance)})
+		for distance < best && !atomic.CompareAndSwapInt64(m.best, best, distance) {
+			best = atomic.LoadInt64(m.best)
+		}
+		return
+	}
+
+	for base := byte(0); base < 4; base++ {
+		m.extend(depth, base)
+		m.visit(depth + 1)
+	}
+}
+
+// DistanceBetweenPatternAndStrings is the sum over the strings of dna of the smallest Hamming distance
+// between pattern and a k-mer of the string.
 func DistanceBetweenPatternAndStrings(dna sequences, pattern sequence) int {
 	k := len(pattern)
 
@@ -108,15 +342,15 @@ func DistanceBetweenPatternAndStrings(dna sequences, pattern sequence) int {
 		distance = distance + hamming
 	}
 
-	fmt.Printf("DistanceBetweenPatternAndStrings: %v result=%v\n", DeNormalizeDNA(pattern), distance)
-
 	return distance
 }
 
+// MostProbableKmer finds the k-mer of dna with the highest probability under the profile.
+// On ties, and when every k-mer has probability 0, the first k-mer is returned.
 func MostProbableKmer(dna sequence, k int, matrix ProfileMatrix) sequence {
 
 	var bestPattern sequence
-	best := 0.0
+	best := -1.0
 	for i := 0; i <= len(dna)-k; i++ {
 		kmer := dna[i : i+k]
 		score := matrix.Score(kmer)
@@ -129,6 +363,23 @@ func MostProbableKmer(dna sequence, k int, matrix ProfileMatrix) sequence {
 	return bestPattern
 }
 
+// MostProbableKmerBackground finds the k-mer with the highest log-odds score against the background.
+// Unlike MostProbableKmer this takes into account that some k-mers are common in any sequence.
+func MostProbableKmerBackground(dna sequence, k int, matrix ProfileMatrix, background Background) sequence {
+	var bestPattern sequence
+	best := math.Inf(-1)
+	for i := 0; i <= len(dna)-k; i++ {
+		kmer := dna[i : i+k]
+		score := matrix.LogOddsScore(kmer, background)
+		if score > best {
+			best = score
+			bestPattern = kmer
+		}
+	}
+
+	return bestPattern
+}
+
 func (mat *ProfileMatrix) Get(nuc int, pos int) float64 {
 	return mat.data[nuc][pos]
 }
@@ -142,6 +393,71 @@ func (mat *ProfileMatrix) Score(pattern sequence) float64 {
 	return score
 }
 
+// LogOddsScore is the log-likelihood of pattern under the profile, minus its log-likelihood under the background.
+// A positive score means the pattern looks more like the motif than like background DNA.
+func (mat *ProfileMatrix) LogOddsScore(pattern sequence, background Background) float64 {
+	logP := 0.0
+	for i := 0; i < len(pattern); i++ {
+		logP += math.Log(mat.Get(int(pattern[i]), i))
+	}
+
+	return logP - background.LogLikelihood(pattern)
+}
+
 type ProfileMatrix struct {
 	data [][]float64
 }
+
+/*
+   GreedyMotifSearch(Dna, k, t)
+       BestMotifs ← motif matrix formed by first k-mers in each string from Dna
+       for each k-mer Motif in the first string from Dna
+           Motif1 ← Motif
+           for i = 2 to t
+               form Profile from motifs Motif1, …, Motifi - 1
+               Motifi ← Profile-most probable k-mer in the i-th string in Dna
+           Motifs ← (Motif1, …, Motift)
+           if Score(Motifs) < Score(BestMotifs)
+               BestMotifs ← Motifs
+       return BestMotifs
+*/
+
+// GreedyMotifSearch finds a motif of length k in each of the first t strings of dna, and returns the motifs and their Score.
+// With pseudocounts, the profiles are made with Laplace's rule of succession.
+// Ties keep the motifs found first, from the earliest k-mer of the first string.
+// When a string is shorter than k there is no motif in it, and no motifs are returned.
+func GreedyMotifSearch(dna sequences, k, t int, pseudocounts bool) (sequences, int) {
+	dna = dna[:Max(Min(t, len(dna)), 0)]
+	if err := validateMotifSearch(dna, k); err != nil {
+		return sequences{}, 0
+	}
+	t = len(dna)
+
+	pseudocount := 0.0
+	if pseudocounts {
+		pseudocount = 1
+	}
+
+	bestMotifs := make(sequences, t)
+	for i := range dna {
+		bestMotifs[i] = dna[i][:k]
+	}
+	bestScore := Score(bestMotifs)
+
+	first := dna[0]
+	for i := 0; i <= len(first)-k; i++ {
+		motifs := make(sequences, 1, t)
+		motifs[0] = first[i : i+k]
+		for j := 1; j < t; j++ {
+			// the motifs are all k long, checked above, so the profile is valid
+			profile, _ := NewProfileFromMotifs(motifs, pseudocount)
+			motifs = append(motifs, MostProbableKmer(dna[j], k, profile))
+		}
+
+		if score := Score(motifs); score < bestScore {
+			bestMotifs, bestScore = motifs, score
+		}
+	}
+
+	return bestMotifs, bestScore
+}
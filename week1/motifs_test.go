@@ -1,6 +1,7 @@
 package main
 
 import (
+	"math/rand"
 	"sort"
 	"testing"
 
@@ -37,6 +38,92 @@ func TestMotifEnumeration_debug2(t *testing.T) {
 	assert.Equal(t, []string{"AAA", "AAC", "AAG", "AAT", "ACA", "AGA", "ATA", "CAA", "GAA", "TAA"}, results)
 }
 
+func TestMotifEnumerationOrder(t *testing.T) {
+	dna := NormalizeListDNA([]string{"ATTTGGC", "TGCCTTA", "CGGTATC", "GAAAATT"})
+
+	// sorted without sorting the result
+	assert.Equal(t, []string{"ATA", "ATT", "GTT", "TTT"}, MotifEnumeration(dna, 3, 1).DeNormalize())
+}
+
+func TestMotifEnumerationQuorum(t *testing.T) {
+	dna := NormalizeListDNA([]string{"AAAA", "AAAA", "CCCC", "AACC"})
+
+	assert.Equal(t, []string{}, MotifEnumerationQuorum(dna, 3, 0, 4).DeNormalize())
+	assert.Equal(t, []string{"AAA"}, MotifEnumerationQuorum(dna, 3, 0, 2).DeNormalize())
+	assert.Equal(t, []string{"AAA", "AAC", "ACC", "CCC"}, MotifEnumerationQuorum(dna, 3, 0, 1).DeNormalize())
+	// one mismatch from AAA in the first two, and from AAC or ACC in AACC
+	assert.Equal(t, []string{"AAA", "AAC", "AAG", "AAT", "ACA"}, MotifEnumerationQuorum(dna, 3, 1, 3).DeNormalize())
+	assert.Equal(t, []string{}, MotifEnumerationQuorum(dna, 3, 1, 5).DeNormalize())
+}
+
+func TestMotifEnumerationMatchesScan(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	dna := make(sequences, 5)
+	for i := range dna {
+		dna[i] = make(sequence, 30)
+		for j := range dna[i] {
+			dna[i][j] = byte(random.Intn(4))
+		}
+	}
+
+	for q := 1; q <= len(dna); q++ {
+		expected := []string{}
+		for index := 0; index < int(Pow4(5)); index++ {
+			pattern := NormalizeDNA(IndexToPatternStr(5, index))
+			found := 0
+			for _, text := range dna {
+				if len(ApproximateSubStringHits(DeNormalizeDNA(text), DeNormalizeDNA(pattern), 1, false)) > 0 {
+					found++
+				}
+			}
+			if found >= q {
+				expected = append(expected, DeNormalizeDNA(pattern))
+			}
+		}
+		assert.Equal(t, expected, MotifEnumerationQuorum(dna, 5, 1, q).DeNormalize(), "q=%v", q)
+	}
+}
+
+func TestEachNeighborIndex(t *testing.T) {
+	indexes := []int{}
+	eachNeighborIndex(NormalizeDNA("ACG"), 1, func(index int) {
+		indexes = append(indexes, index)
+	})
+	// the pattern and 3 changes at each of 3 positions
+	assert.Len(t, indexes, 10)
+	neighbors := []string{}
+	for _, index := range indexes {
+		neighbors = append(neighbors, IndexToPatternStr(3, index))
+	}
+	sort.Strings(neighbors)
+	expected := NeighborsSimple(NormalizeDNA("ACG"), 1)
+	expectedStrs := sequences(expected).DeNormalize()
+	sort.Strings(expectedStrs)
+	assert.Equal(t, expectedStrs, neighbors)
+}
+
+func TestKmerSet(t *testing.T) {
+	set := newKmerSet(200)
+	set.add(3)
+	set.add(64)
+	set.add(199)
+	assert.True(t, set.has(64))
+	assert.False(t, set.has(65))
+
+	indexes := []int{}
+	set.each(func(index int) {
+		indexes = append(indexes, index)
+	})
+	assert.Equal(t, []int{3, 64, 199}, indexes)
+
+	other := newKmerSet(200)
+	other.add(64)
+	set.intersect(other)
+	assert.False(t, set.empty())
+	set.intersect(newKmerSet(200))
+	assert.True(t, set.empty())
+}
+
 func TestDistanceBetweenPatternAndStrings(t *testing.T) {
 	dna := NormalizeListDNA([]string{
 		"TTACCTTAAC",
@@ -167,7 +254,8 @@ func TestMedianString_debug1(t *testing.T) {
 	pats := MedianString(dna, 3).DeNormalize()
 	sort.Strings(pats)
 
-	assert.Equal(t, []string{"ACG"}, pats)
+	// ACG and CGT are both in every string
+	assert.Equal(t, []string{"ACG", "CGT"}, pats)
 
 }
 
@@ -187,6 +275,26 @@ func TestMostProbableKmer(t *testing.T) {
 
 }
 
+func TestMostProbableKmerBackground(t *testing.T) {
+	pMat := ProfileMatrix{
+		data: [][]float64{
+			{0.2, 0.2, 0.3, 0.2, 0.3}, // A
+			{0.4, 0.3, 0.1, 0.5, 0.1}, // C
+			{0.3, 0.3, 0.5, 0.2, 0.4}, // G
+			{0.1, 0.2, 0.1, 0.1, 0.2}, // T
+		},
+	}
+	dna := NormalizeDNA("ACCTGTTTATTGCCTAAGTTCCGAACAAACCCAATATAGCCCGAGGGCCT")
+
+	uniform := &MarkovModel{Order: 0, Initial: []float64{1}, Transitions: []float64{0.25, 0.25, 0.25, 0.25}}
+	assert.Equal(t, "CCGAG", DeNormalizeDNA(MostProbableKmerBackground(dna, 5, pMat, uniform)))
+	assert.True(t, pMat.LogOddsScore(NormalizeDNA("CCGAG"), uniform) > 0)
+
+	// with a background rich in C, the C-heavy k-mers are less surprising
+	cRich := &MarkovModel{Order: 0, Initial: []float64{1}, Transitions: []float64{0.1, 0.7, 0.1, 0.1}}
+	assert.Equal(t, "GAGGG", DeNormalizeDNA(MostProbableKmerBackground(dna, 5, pMat, cRich)))
+}
+
 const dataset_159_3 = "CTTGTAGGTCGAGACTCCTTGGCATGCCACAAAACATGATATTTGCATCGGCCGTGTCATCCATCTGCTTTGACAGCAGCCCGTTCTTGCACATCCAACGAACGAAGAATCCCCAACCGGGTTTGCGTACTTCCGCAACATTCGTAGCCACATCCTATGTCAGTTCGCGTAGTCCGCCGCAAGGTCCGCGAGTAAGTGTCTGGTAGCCTACGACTCAAACAACACGGTGCTATTCTCCATGTCGGTCTGAGAATAGTACACTCCACAGCGTCCAATTGACAAGTCCGATCGAAATGGACCTGAGTATGGTTTATAATCGTCCAGGTGGCATCCAACTAAAGGCCAAAGATGTCGTCTTGTATGATGGCTCCCTTCTCTGTTGATTCTAGCGCGCTTGTCAATATGTACGTCATGCAAAGTGAATAAATGGCACATCGGCCGGAATTCGCATATGTGCAGTCGCTGTCAAGGCAATCCCCTACTGGGGTACGTGCGGTTCGTGTGGGAAAATGTGTCACGAACAAAGAATTTCAGTACATAGCTTTACCGTATTTCCATCGCATCAACCATTATGTCTTGCGTAGAAATTTTCAAGGTCCACTACGGGATTTTAGTTTCTAGAATCGTTAGCTCGAGACCAATCAAGTTGAAAAGAGTAACCCATTCGTTTCCGGGCCATATCGGGAGCACTACATAGTGGGCTTAGTAGGAGACCTTGGGGCTAGGACCAACTTCCGCTCGCCTGTGTAATCCCGCTACGACCCGTGGTTAGGAGGGGATTCCGTTACGATGACATCTTATAGGAGCCATTGAATGCAGCATACCCTGCTAACTTAGGCTCAAATACCGATTCCCAATGTATGGGCTCCCCGAAGTCAGTCGCAGGGTCTTTGCTCCATAGTTGTAGTCACGTTCTAACTGGTGCGGATGATAACACACAGCATACTTGATTCTTCGATAGTTTGCAGCTTAGCGATAGATCACTGCCAACCTATACATC"
 
 func TestMostProbableKmer_dataset_159_3(t *testing.T) {
@@ -221,7 +329,7 @@ func TestMedianString_dataset_158_9(t *testing.T) {
 	pats := MedianString(dna, 6).DeNormalize()
 	sort.Strings(pats)
 
-	assert.Equal(t, []string{"ACG"}, pats)
+	assert.Equal(t, []string{"TCGTAG"}, pats)
 
 }
 
@@ -235,8 +343,25 @@ func TestMedianString_dataset_158_9_(t *testing.T) {
 	pats := MedianString(dna, 7).DeNormalize()
 	sort.Strings(pats)
 
-	assert.Equal(t, []string{"ACG"}, pats)
+	assert.Equal(t, []string{"AATCCTA", "GAACCAC", "GTAGGAA", "TAGTTTC"}, pats)
+
+}
+
+func TestMedianStringLongK(t *testing.T) {
+	dna := NormalizeListDNA([]string{
+		"TTGACCATGATTGCCAAGGTTTACGGA",
+		"GCGTACGATTGCCAAGGAGTTAACCGT",
+		"ACGTTTATTGCCAAGGTATCGGACCTA",
+	})
+
+	pats := MedianString(dna, 10).DeNormalize()
+	assert.Equal(t, []string{"ATTGCCAAGG"}, pats)
+	assert.Equal(t, 0, DistanceBetweenPatternAndStrings(dna, NormalizeDNA(pats[0])))
+}
 
+func TestMedianStringShortStrings(t *testing.T) {
+	assert.Equal(t, sequences{}, MedianString(NormalizeListDNA([]string{"ACGT", "AC"}), 3))
+	assert.Equal(t, sequences{}, MedianString(sequences{}, 3))
 }
 
 //func TestSubtleMotifDataset(t *testing.T) {
@@ -761,3 +886,62 @@ func TestMotifEnumeration_Dataset_motif_8(t *testing.T) {
 
 	assert.Equal(t, dataset_motif_8_expected, results)
 }
+
+func TestMostProbableKmerZeroProbability(t *testing.T) {
+	pMat := ProfileMatrix{
+		data: [][]float64{
+			{1, 1}, // A
+			{0, 0}, // C
+			{0, 0}, // G
+			{0, 0}, // T
+		},
+	}
+
+	kmer := MostProbableKmer(NormalizeDNA("CGTCG"), 2, pMat)
+	assert.Equal(t, "CG", DeNormalizeDNA(kmer))
+}
+
+func TestGreedyMotifSearch(t *testing.T) {
+	dna := NormalizeListDNA([]string{
+		"GGCGTTCAGGCA",
+		"AAGAATCAGTCA",
+		"CAAGGAGTTCGC",
+		"CACGTCAATCAC",
+		"CAATAATATTCG",
+	})
+
+	motifs, score := GreedyMotifSearch(dna, 3, 5, false)
+	assert.Equal(t, []string{"CAG", "CAG", "CAA", "CAA", "CAA"}, motifs.DeNormalize())
+	assert.Equal(t, Score(motifs), score)
+	assert.Equal(t, 2, score)
+
+	motifs, score = GreedyMotifSearch(dna, 3, 5, true)
+	assert.Equal(t, []string{"TTC", "ATC", "TTC", "ATC", "TTC"}, motifs.DeNormalize())
+	assert.Equal(t, 2, score)
+}
+
+func TestGreedyMotifSearchTies(t *testing.T) {
+	// every k-mer gives motifs with the same score, so the first one is kept
+	dna := NormalizeListDNA([]string{"ACGT", "ACGT"})
+
+	motifs, score := GreedyMotifSearch(dna, 2, 2, false)
+	assert.Equal(t, []string{"AC", "AC"}, motifs.DeNormalize())
+	assert.Equal(t, 0, score)
+
+	motifs, score = GreedyMotifSearch(sequences{}, 2, 2, false)
+	assert.Equal(t, sequences{}, motifs)
+	assert.Equal(t, 0, score)
+}
+
+func TestGreedyMotifSearchShortString(t *testing.T) {
+	dna := NormalizeListDNA([]string{"ACGTACGT", "TT", "ACGTT"})
+
+	motifs, score := GreedyMotifSearch(dna, 3, 3, false)
+	assert.Equal(t, sequences{}, motifs)
+	assert.Equal(t, 0, score)
+
+	// the short string is not among the first t
+	motifs, score = GreedyMotifSearch(NormalizeListDNA([]string{"ACGTACGT", "ACGTT", "TT"}), 3, 2, false)
+	assert.Equal(t, []string{"ACG", "ACG"}, motifs.DeNormalize())
+	assert.Equal(t, 0, score)
+}
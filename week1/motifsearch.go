@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MotifSearchOptions configures the searches run with many random restarts.
+type MotifSearchOptions struct {
+	Restarts int
+	// Workers is the number of restarts run at the same time, runtime.NumCPU() when 0.
+	Workers int
+}
+
+// DefaultMotifSearchOptions runs 1000 restarts on all CPUs.
+func DefaultMotifSearchOptions() MotifSearchOptions {
+	return MotifSearchOptions{Restarts: 1000}
+}
+
+// MotifSearchResult holds the best motifs found over all restarts.
+type MotifSearchResult struct {
+	Motifs sequences
+	Score  int
+	// Scores holds the score of every completed restart, in the order the restarts were started.
+	Scores []int
+	// Trace is the best score after every iteration of the best restart.
+	Trace []int
+}
+
+// Distribution summarizes the scores of the restarts.
+func (r *MotifSearchResult) Distribution() ScoreDistribution {
+	return NewScoreDistribution(r.Scores)
+}
+
+// motifSearch is one run of a randomized motif search, drawing all its random numbers from random.
+// It returns the best motifs, their score, and the best score after every iteration.
+type motifSearch func(ctx context.Context, random *rand.Rand) (sequences, int, []int)
+
+/*
+   RunRestarts(Search, n)
+       Seeds ← n numbers drawn from Source
+       for each restart i, on any free worker
+           (Motifsi, Scorei) ← Search(random numbers seeded with Seedsi)
+       return the Motifs with the lowest score, the first restart on ties
+*/
+
+// runRestarts runs search opts.Restarts times on a pool of workers.
+// Every restart draws its own seed from source before any restart runs,
+// so the result is the same for any number of workers.
+// When ctx is cancelled, the restarts not yet started are skipped, and the best of the completed ones is returned with ctx.Err().
+func runRestarts(ctx context.Context, source rand.Source, opts MotifSearchOptions, search motifSearch) (MotifSearchResult, error) {
+	restarts := Max(opts.Restarts, 1)
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	seeds := make([]int64, restarts)
+	random := rand.New(source)
+	for i := range seeds {
+		seeds[i] = random.Int63()
+	}
+
+	motifs := make([]sequences, restarts)
+	scores := make([]int, restarts)
+	traces := make([][]int, restarts)
+	completed := make([]bool, restarts)
+
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < Min(workers, restarts); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				motifs[i], scores[i], traces[i] = search(ctx, rand.New(rand.NewSource(seeds[i])))
+				// a search interrupted by ctx may have stopped early
+				completed[i] = ctx.Err() == nil
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < restarts; i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := MotifSearchResult{Motifs: sequences{}, Scores: []int{}, Trace: []int{}}
+	best := -1
+	for i := range completed {
+		if !completed[i] {
+			continue
+		}
+		result.Scores = append(result.Scores, scores[i])
+		if best == -1 || scores[i] < scores[best] {
+			best = i
+		}
+	}
+	if best != -1 {
+		result.Motifs, result.Score, result.Trace = motifs[best], scores[best], traces[best]
+	}
+
+	return result, ctx.Err()
+}
+
+/*
+   RandomizedMotifSearch(Dna, k, t)
+       randomly select k-mers Motifs = (Motif1, …, Motift) in each string from Dna
+       BestMotifs ← Motifs
+       while forever
+           Profile ← Profile(Motifs)
+           Motifs ← Motifs(Profile, Dna)
+           if Score(Motifs) < Score(BestMotifs)
+               BestMotifs ← Motifs
+           else
+               return BestMotifs
+*/
+
+// RandomizedMotifSearch starts from a random k-mer in each string of dna, and alternates making a profile with pseudocounts
+// from the motifs and picking the most probable k-mers of the profile, until the score stops improving.
+// It returns the motifs and their Score, or no motifs when dna is empty or a string is shorter than k.
+func RandomizedMotifSearch(dna sequences, k int, random *rand.Rand) (sequences, int) {
+	if err := validateMotifSearch(dna, k); err != nil {
+		return sequences{}, 0
+	}
+	motifs, score, _ := randomizedMotifSearch(context.Background(), dna, k, random)
+	return motifs, score
+}
+
+// RandomizedMotifSearchRestarts runs RandomizedMotifSearch from many random starts, and keeps the best motifs.
+func RandomizedMotifSearchRestarts(ctx context.Context, dna sequences, k int, source rand.Source, opts MotifSearchOptions) (MotifSearchResult, error) {
+	if err := validateMotifSearch(dna, k); err != nil {
+		return MotifSearchResult{}, err
+	}
+	return runRestarts(ctx, source, opts, func(ctx context.Context, random *rand.Rand) (sequences, int, []int) {
+		return randomizedMotifSearch(ctx, dna, k, random)
+	})
+}
+
+func randomizedMotifSearch(ctx context.Context, dna sequences, k int, random *rand.Rand) (sequences, int, []int) {
+	bestMotifs := randomMotifs(dna, k, random)
+	bestScore := Score(bestMotifs)
+	trace := []int{bestScore}
+
+	for ctx.Err() == nil {
+		// the callers checked dna, so the motifs are all k long and the profile is valid
+		profile, _ := NewProfileFromMotifs(bestMotifs, 1)
+		motifs := ProfileMotifs(profile, dna)
+		score := Score(motifs)
+		if score >= bestScore {
+			break
+		}
+		bestMotifs, bestScore = motifs, score
+		trace = append(trace, bestScore)
+	}
+
+	return bestMotifs, bestScore, trace
+}
+
+// ProfileMotifs is the most probable k-mer of the profile in each string of dna.
+func ProfileMotifs(profile ProfileMatrix, dna sequences) sequences {
+	motifs := make(sequences, len(dna))
+	for i := range dna {
+		motifs[i] = MostProbableKmer(dna[i], profile.K(), profile)
+	}
+	return motifs
+}
+
+// randomMotifs picks a random k-mer from every string of dna.
+func randomMotifs(dna sequences, k int, random *rand.Rand) sequences {
+	motifs := make(sequences, len(dna))
+	for i := range dna {
+		start := random.Intn(len(dna[i]) - k + 1)
+		motifs[i] = dna[i][start : start+k]
+	}
+	return motifs
+}
+
+func validateMotifSearch(dna sequences, k int) error {
+	if len(dna) == 0 {
+		return fmt.Errorf("no sequences to search")
+	}
+	if k < 1 {
+		return fmt.Errorf("k must be at least 1, got %v", k)
+	}
+	for i := range dna {
+		if len(dna[i]) < k {
+			return fmt.Errorf("sequence %v is shorter than k=%v", i, k)
+		}
+	}
+	return nil
+}
+
+// ScoreDistribution summarizes the scores of many runs of a motif search.
+type ScoreDistribution struct {
+	Runs   int
+	Min    int
+	Max    int
+	Mean   float64
+	Median float64
+	// Counts is the number of runs with each score.
+	Counts map[int]int
+}
+
+// NewScoreDistribution summarizes scores.
+func NewScoreDistribution(scores []int) ScoreDistribution {
+	dist := ScoreDistribution{Runs: len(scores), Counts: map[int]int{}}
+	if len(scores) == 0 {
+		return dist
+	}
+
+	sorted := append([]int{}, scores...)
+	sort.Ints(sorted)
+	dist.Min, dist.Max = sorted[0], sorted[len(sorted)-1]
+
+	sum := 0
+	for _, score := range sorted {
+		sum += score
+		dist.Counts[score]++
+	}
+	dist.Mean = float64(sum) / float64(len(sorted))
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		dist.Median = float64(sorted[middle-1]+sorted[middle]) / 2
+	} else {
+		dist.Median = float64(sorted[middle])
+	}
+	return dist
+}
+
+// Write writes a summary line and a histogram with one line per score, lowest first.
+func (d ScoreDistribution) Write(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "runs=%d min=%d max=%d mean=%.2f median=%.1f\n", d.Runs, d.Min, d.Max, d.Mean, d.Median)
+
+	maxCount := 0
+	for _, count := range d.Counts {
+		maxCount = Max(maxCount, count)
+	}
+	for score := d.Min; score <= d.Max && d.Runs > 0; score++ {
+		count := d.Counts[score]
+		bar := strings.Repeat("#", (50*count+maxCount-1)/maxCount)
+		fmt.Fprintf(out, "%d\t%d\t%v\n", score, count, bar)
+	}
+	return out.Flush()
+}
+
+// readMotifDataset reads one sequence per line, leaving out blank lines and the '*' marking implanted motifs.
+func readMotifDataset(r io.Reader) (sequences, error) {
+	dna := sequences{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.ToUpper(strings.Replace(strings.TrimSpace(scanner.Text()), "*", "", -1))
+		if line != "" {
+			dna = append(dna, NormalizeDNA(line))
+		}
+	}
+	return dna, scanner.Err()
+}
+
+// motifsCommand searches the sequences in a file, one per line, for a motif of length k,
+// and writes the motifs, their score and consensus, and the score distribution of the restarts to stdout.
+// With -format it writes the count matrix of the motifs in that motif format instead.
+//
+//	motifs [-k 15] [-method randomized] [-n 1000] [-iterations 2000] [-burn-in 100] [-seed 1] [-workers 0] [-timeout 0] [-format jaspar] sequences.txt
+func motifsCommand(args []string) error {
+	opts := DefaultMotifSearchOptions()
+	gibbs := DefaultGibbsOptions()
+	flags := flag.NewFlagSet("motifs", flag.ContinueOnError)
+	k := flags.Int("k", 15, "length of the motif")
+	method := flags.String("method", "randomized", "search method: greedy, randomized or gibbs")
+	flags.IntVar(&opts.Restarts, "n", opts.Restarts, "number of random restarts")
+	flags.IntVar(&gibbs.Iterations, "iterations", gibbs.Iterations, "iterations of each Gibbs sampler restart")
+	flags.IntVar(&gibbs.BurnIn, "burn-in", gibbs.BurnIn, "iterations of the Gibbs sampler before the best motifs are tracked")
+	flags.IntVar(&opts.Workers, "workers", opts.Workers, "restarts run at the same time, 0 for one per CPU")
+	seed := flags.Int64("seed", 1, "random seed")
+	timeout := flags.Duration("timeout", 0, "stop starting new restarts after this long, 0 for no limit")
+	format := flags.String("format", "", "write the motifs found as a jaspar, meme or transfac motif instead")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: motifs [flags] sequences.txt")
+	}
+	if err := gibbs.validate(); err != nil {
+		return err
+	}
+	var motifFormat MotifFormat
+	if *format != "" {
+		var err error
+		if motifFormat, err = ParseMotifFormat(*format); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	dna, err := readMotifDataset(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	var result MotifSearchResult
+	switch *method {
+	case "greedy":
+		if err := validateMotifSearch(dna, *k); err != nil {
+			return err
+		}
+		motifs, score := GreedyMotifSearch(dna, *k, len(dna), true)
+		result = MotifSearchResult{Motifs: motifs, Score: score, Scores: []int{score}, Trace: []int{score}}
+	case "randomized":
+		result, err = RandomizedMotifSearchRestarts(ctx, dna, *k, rand.NewSource(*seed), opts)
+	case "gibbs":
+		gibbs.MotifSearchOptions = opts
+		if !flagSet(flags, "n") {
+			gibbs.Restarts = DefaultGibbsOptions().Restarts
+		}
+		result, err = GibbsSamplerRestarts(ctx, dna, *k, rand.NewSource(*seed), gibbs)
+	default:
+		return fmt.Errorf("unknown method %v", *method)
+	}
+	if err == context.DeadlineExceeded {
+		fmt.Fprintf(os.Stderr, "timeout after %v restarts\n", len(result.Scores))
+	} else if err != nil {
+		return err
+	}
+
+	if *format != "" {
+		motif, err := NewMotifFromSites(sampleName(flags.Arg(0)), result.Motifs)
+		if err != nil {
+			return err
+		}
+		motif.Name = DeNormalizeDNA(Consensus(result.Motifs))
+		return WriteMotifs(os.Stdout, []Motif{motif}, motifFormat)
+	}
+	return writeMotifSearchResult(os.Stdout, result)
+}
+
+// flagSet tells if the flag name was given on the command line.
+func flagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func writeMotifSearchResult(w io.Writer, result MotifSearchResult) error {
+	for _, motif := range result.Motifs.DeNormalize() {
+		fmt.Fprintln(w, motif)
+	}
+	if len(result.Motifs) > 0 {
+		fmt.Fprintf(w, "consensus=%v score=%d\n", DeNormalizeDNA(Consensus(result.Motifs)), result.Score)
+	}
+	return result.Distribution().Write(w)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var randomizedMotifDataset = []string{
+	"CGCCCCTCTCGGGGGTGTTCAGTAAACGGCCA",
+	"GGGCGAGGTATGTGTAAGTGCCAAGGTGCCAG",
+	"TAGTACCGAGACCGAAAGAAGTATACAGGCGT",
+	"TAGATCAAGTTTCAGGTGCACGTCGGTGAACC",
+	"AATCCACCAGCTCCACGTGCAATGTTGGCCTA",
+}
+
+func TestRandomizedMotifSearch(t *testing.T) {
+	dna := NormalizeListDNA(randomizedMotifDataset)
+
+	motifs, score := RandomizedMotifSearch(dna, 8, rand.New(rand.NewSource(1)))
+	assert.Len(t, motifs, 5)
+	assert.Equal(t, Score(motifs), score)
+	for i := range motifs {
+		assert.Len(t, motifs[i], 8)
+	}
+}
+
+func TestRandomizedMotifSearchShortString(t *testing.T) {
+	dna := NormalizeListDNA([]string{"ACGTACGT", "TT", "ACGTT"})
+
+	motifs, score := RandomizedMotifSearch(dna, 3, rand.New(rand.NewSource(1)))
+	assert.Equal(t, sequences{}, motifs)
+	assert.Equal(t, 0, score)
+
+	motifs, score = RandomizedMotifSearch(sequences{}, 3, rand.New(rand.NewSource(1)))
+	assert.Equal(t, sequences{}, motifs)
+	assert.Equal(t, 0, score)
+}
+
+func TestRandomizedMotifSearchRestarts(t *testing.T) {
+	dna := NormalizeListDNA(randomizedMotifDataset)
+	expected := []string{"TCTCGGGG", "CCAAGGTG", "TACAGGCG", "TTCAGGTG", "TCCACGTG"}
+
+	opts := MotifSearchOptions{Restarts: 1000, Workers: 4}
+	result, err := RandomizedMotifSearchRestarts(context.Background(), dna, 8, rand.NewSource(1), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result.Motifs.DeNormalize())
+	assert.Equal(t, Score(NormalizeListDNA(expected)), result.Score)
+	assert.Len(t, result.Scores, 1000)
+
+	dist := result.Distribution()
+	assert.Equal(t, 1000, dist.Runs)
+	assert.Equal(t, result.Score, dist.Min)
+
+	// the same seed gives the same result with any number of workers
+	opts.Workers = 1
+	again, err := RandomizedMotifSearchRestarts(context.Background(), dna, 8, rand.NewSource(1), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, result, again)
+}
+
+func TestRandomizedMotifSearchRestartsCancel(t *testing.T) {
+	dna := NormalizeListDNA(randomizedMotifDataset)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := RandomizedMotifSearchRestarts(ctx, dna, 8, rand.NewSource(1), MotifSearchOptions{Restarts: 100, Workers: 2})
+	assert.Equal(t, context.Canceled, err)
+	assert.True(t, len(result.Scores) < 100)
+}
+
+func TestRandomizedMotifSearchRestartsInvalid(t *testing.T) {
+	_, err := RandomizedMotifSearchRestarts(context.Background(), NormalizeListDNA([]string{"ACGT"}), 5, rand.NewSource(1), MotifSearchOptions{})
+	assert.Error(t, err)
+	_, err = RandomizedMotifSearchRestarts(context.Background(), sequences{}, 5, rand.NewSource(1), MotifSearchOptions{})
+	assert.Error(t, err)
+}
+
+func TestRandomizedMotifSearchSubtleMotif(t *testing.T) {
+	file, err := os.Open("subtle_motif.txt")
+	assert.NoError(t, err)
+	defer file.Close()
+	dna, err := readMotifDataset(file)
+	assert.NoError(t, err)
+	assert.Len(t, dna, 10)
+	assert.Len(t, dna[0], 600)
+
+	result, err := RandomizedMotifSearchRestarts(context.Background(), dna, 15, rand.NewSource(1), MotifSearchOptions{Restarts: 500})
+	assert.NoError(t, err)
+	assert.Len(t, result.Scores, 500)
+	// the implanted motif AAAAAAAAGGGGGGG has 4 mutations in each copy, so it scores about 40.
+	// Randomized search rarely finds it exactly, but gets close to an overlapping motif
+	assert.True(t, result.Score <= 50, "score %v", result.Score)
+	assert.True(t, float64(result.Score) < result.Distribution().Median)
+}
+
+func TestScoreDistribution(t *testing.T) {
+	dist := NewScoreDistribution([]int{5, 3, 3, 4})
+	assert.Equal(t, ScoreDistribution{Runs: 4, Min: 3, Max: 5, Mean: 3.75, Median: 3.5, Counts: map[int]int{3: 2, 4: 1, 5: 1}}, dist)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, dist.Write(&buf))
+	assert.Equal(t, "runs=4 min=3 max=5 mean=3.75 median=3.5\n"+
+		"3\t2\t"+strings.Repeat("#", 50)+"\n"+
+		"4\t1\t"+strings.Repeat("#", 25)+"\n"+
+		"5\t1\t"+strings.Repeat("#", 25)+"\n", buf.String())
+
+	assert.Equal(t, ScoreDistribution{Counts: map[int]int{}}, NewScoreDistribution([]int{}))
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// OriOptions configures PredictOri.
+type OriOptions struct {
+	// WindowLength is the length of the window around each skew minimum searched for DnaA boxes.
+	WindowLength int
+	// K is the length of the DnaA boxes.
+	K int
+	// Mismatches is the number of mismatches allowed when counting candidate boxes.
+	Mismatches int
+	// Consensus holds known DnaA boxes the candidates are compared with.
+	Consensus []string
+	// Candidates is the number of candidate boxes reported per window.
+	Candidates int
+	Topology   Topology
+}
+
+// DefaultOriOptions looks for 9-mers with one mismatch in 500 bp windows, like the E. coli DnaA box TTATCCACA.
+func DefaultOriOptions() OriOptions {
+	return OriOptions{
+		WindowLength: 500,
+		K:            9,
+		Mismatches:   1,
+		Consensus:    []string{"TTATCCACA"},
+		Candidates:   10,
+		Topology:     Circular,
+	}
+}
+
+// validate checks the options before they are used to slice windows and count k-mers.
+func (opts OriOptions) validate() error {
+	if opts.K < 1 {
+		return fmt.Errorf("k must be at least 1, got %v", opts.K)
+	}
+	if opts.WindowLength < opts.K {
+		return fmt.Errorf("window length must be at least k (%v), got %v", opts.K, opts.WindowLength)
+	}
+	if opts.Mismatches < 0 {
+		return fmt.Errorf("mismatches must be at least 0, got %v", opts.Mismatches)
+	}
+	if opts.Candidates < 0 {
+		return fmt.Errorf("candidates must be at least 0, got %v", opts.Candidates)
+	}
+	return nil
+}
+
+// OriPrediction is the result of PredictOri, with the most likely window first.
+type OriPrediction struct {
+	GenomeLength int         `json:"genomeLength"`
+	MinSkew      int         `json:"minSkew"`
+	Windows      []OriWindow `json:"windows"`
+}
+
+// OriWindow is a candidate origin of replication around a skew minimum.
+type OriWindow struct {
+	Start        int `json:"start"`
+	End          int `json:"end"`
+	SkewPosition int `json:"skewPosition"`
+	// ConsensusHits are the occurrences of the consensus boxes in the window, with at most Mismatches mismatches.
+	ConsensusHits []Hit     `json:"consensusHits"`
+	Boxes         []DnaABox `json:"boxes"`
+}
+
+// DnaABox is a frequent k-mer in an ori window, counted on both strands with mismatches.
+type DnaABox struct {
+	Pattern string `json:"pattern"`
+	Count   int    `json:"count"`
+	// ConsensusDistance is the smallest Hamming distance to a consensus box on either strand, -1 without consensus.
+	ConsensusDistance int   `json:"consensusDistance"`
+	Hits              []Hit `json:"hits"`
+}
+
+/*
+   PredictOri(Genome)
+       find the positions of minimum skew
+       for each minimum, not already inside a window
+           Window ← the WindowLength bases centered on the minimum
+           count all k-mers of Window with mismatches on both strands
+           keep the most frequent ones as candidate DnaA boxes
+       rank the windows by how many boxes similar to the consensus they have
+*/
+
+// PredictOri predicts the origin of replication of genome from the skew minimum and the DnaA boxes around it.
+// An empty genome has no windows.
+func PredictOri(genome string, opts OriOptions) OriPrediction {
+	genome = strings.ToUpper(genome)
+	normDNA := NormalizeDNA(genome)
+	dnaLen := len(normDNA)
+	if dnaLen == 0 {
+		return OriPrediction{Windows: []OriWindow{}}
+	}
+
+	positions, minSkew := MinSkewTopology(normDNA, opts.Topology)
+	prediction := OriPrediction{
+		GenomeLength: dnaLen,
+		MinSkew:      minSkew,
+		Windows:      []OriWindow{},
+	}
+
+	windowLength := Min(opts.WindowLength, dnaLen)
+	centers := []int{}
+	for _, pos := range positions {
+		if len(centers) > 0 && opts.Topology.distance(pos, centers[len(centers)-1], dnaLen) <= windowLength/2 {
+			// this minimum is inside the previous window
+			continue
+		}
+		centers = append(centers, pos)
+	}
+	// on a circular genome the last window can reach round the origin into the first
+	if last := len(centers) - 1; last > 0 && opts.Topology.distance(centers[last], centers[0], dnaLen) <= windowLength/2 {
+		centers = centers[:last]
+	}
+	for _, pos := range centers {
+		prediction.Windows = append(prediction.Windows, predictOriWindow(genome, pos, windowLength, opts))
+	}
+
+	sort.SliceStable(prediction.Windows, func(i, j int) bool {
+		a, b := prediction.Windows[i], prediction.Windows[j]
+		if len(a.ConsensusHits) != len(b.ConsensusHits) {
+			return len(a.ConsensusHits) > len(b.ConsensusHits)
+		}
+		return a.bestCount() > b.bestCount()
+	})
+
+	return prediction
+}
+
+func predictOriWindow(genome string, skewPos, windowLength int, opts OriOptions) OriWindow {
+	dnaLen := len(genome)
+
+	start := skewPos - windowLength/2
+	if opts.Topology == Circular {
+		start = (start + dnaLen) % dnaLen
+	} else {
+		start = Max(0, Min(start, dnaLen-windowLength))
+	}
+	text := opts.Topology.extendStr(genome, windowLength)[start : start+windowLength]
+
+	window := OriWindow{
+		Start:         start,
+		End:           opts.Topology.wrap(start+windowLength-1, dnaLen) + 1,
+		SkewPosition:  skewPos,
+		ConsensusHits: []Hit{},
+		Boxes:         []DnaABox{},
+	}
+
+	toGenome := func(hits []Hit) []Hit {
+		for i := range hits {
+			hits[i].Pos = opts.Topology.wrap(start+hits[i].Pos, dnaLen)
+		}
+		return hits
+	}
+
+	for _, consensus := range opts.Consensus {
+		hits := ApproximateSubStringHits(text, strings.ToUpper(consensus), opts.Mismatches, true)
+		window.ConsensusHits = append(window.ConsensusHits, toGenome(hits)...)
+	}
+	sortHits(window.ConsensusHits)
+
+	candidates := NewIndexWithMismatches(NormalizeDNA(text), opts.K, opts.Mismatches, true).TopN(opts.Candidates)
+	for _, candidate := range candidates {
+		window.Boxes = append(window.Boxes, DnaABox{
+			Pattern:           candidate.Pattern,
+			Count:             candidate.Count + candidate.RevCount,
+			ConsensusDistance: consensusDistance(candidate.Pattern, opts.Consensus),
+			Hits:              toGenome(ApproximateSubStringHits(text, candidate.Pattern, opts.Mismatches, true)),
+		})
+	}
+
+	// the most frequent boxes first, and the ones most like the consensus among equally frequent
+	sort.SliceStable(window.Boxes, func(i, j int) bool {
+		a, b := window.Boxes[i], window.Boxes[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return distanceRank(a.ConsensusDistance) < distanceRank(b.ConsensusDistance)
+	})
+
+	return window
+}
+
+func (w OriWindow) bestCount() int {
+	if len(w.Boxes) == 0 {
+		return 0
+	}
+	return w.Boxes[0].Count
+}
+
+// consensusDistance is the smallest Hamming distance from pattern or its reverse complement to a consensus box.
+func consensusDistance(pattern string, consensus []string) int {
+	best := -1
+	revPattern := RevComplementStr(pattern)
+	for _, box := range consensus {
+		box = strings.ToUpper(box)
+		if len(box) != len(pattern) {
+			continue
+		}
+		distance := Min(HammingDistanceStr(pattern, box), HammingDistanceStr(revPattern, box))
+		if best == -1 || distance < best {
+			best = distance
+		}
+	}
+	return best
+}
+
+// distanceRank sorts a missing consensus distance last.
+func distanceRank(distance int) int {
+	if distance < 0 {
+		return int(^uint(0) >> 1)
+	}
+	return distance
+}
+
+// WriteJSON writes the prediction as JSON.
+func (p *OriPrediction) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p)
+}
+
+var oriReportTemplate = template.Must(template.New("ori").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Genome length: {{.GenomeLength}} bp. Minimum skew: {{.MinSkew}}.</p>
+{{range $i, $w := .Windows}}
+<h2>Window {{$i}}: {{$w.Start}}-{{$w.End}}</h2>
+<p>Skew minimum at {{$w.SkewPosition}}. {{len $w.ConsensusHits}} consensus boxes: {{range $w.ConsensusHits}}{{.}} {{end}}</p>
+<table>
+<tr><th>Box</th><th>Count</th><th>Consensus distance</th><th>Hits</th></tr>
+{{range $w.Boxes}}<tr><td><code>{{.Pattern}}</code></td><td>{{.Count}}</td><td>{{.ConsensusDistance}}</td><td>{{range .Hits}}{{.}} {{end}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes the prediction as a HTML report.
+func (p *OriPrediction) WriteHTML(w io.Writer, title string) error {
+	return oriReportTemplate.Execute(w, struct {
+		*OriPrediction
+		Title string
+	}{p, title})
+}
+
+// readGenome reads a FASTA file, or a file with only the sequence.
+func readGenome(filename string) (string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(string(data), ">") {
+		fasta, err := ReadFasta(filename)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(strings.Replace(fasta.Genome(), "\r", "", -1)), nil
+	}
+	return strings.Join(strings.Fields(string(data)), ""), nil
+}
+
+// oriCommand runs PredictOri on a genome file, and writes the report to stdout.
+//
+//	ori [-window 500] [-k 9] [-d 1] [-consensus TTATCCACA] [-linear] [-html] genome.fasta
+func oriCommand(args []string) error {
+	opts := DefaultOriOptions()
+	flags := flag.NewFlagSet("ori", flag.ContinueOnError)
+	flags.IntVar(&opts.WindowLength, "window", opts.WindowLength, "length of the window around each skew minimum")
+	flags.IntVar(&opts.K, "k", opts.K, "length of the DnaA boxes")
+	flags.IntVar(&opts.Mismatches, "d", opts.Mismatches, "mismatches allowed in DnaA boxes")
+	flags.IntVar(&opts.Candidates, "n", opts.Candidates, "candidate boxes reported per window")
+	consensus := flags.String("consensus", strings.Join(opts.Consensus, ","), "comma separated known DnaA boxes")
+	linear := flags.Bool("linear", false, "the genome is linear, not circular")
+	html := flags.Bool("html", false, "write a HTML report instead of JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: ori [flags] genome.fasta")
+	}
+
+	opts.Consensus = []string{}
+	if *consensus != "" {
+		opts.Consensus = strings.Split(*consensus, ",")
+	}
+	if *linear {
+		opts.Topology = Linear
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	genome, err := readGenome(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if genome == "" {
+		return fmt.Errorf("no sequence in %v", flags.Arg(0))
+	}
+
+	prediction := PredictOri(genome, opts)
+	if *html {
+		return prediction.WriteHTML(os.Stdout, "Ori prediction for "+flags.Arg(0))
+	}
+	return prediction.WriteJSON(os.Stdout)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// oriTestGenome is rich in C before position 10000 and rich in G after it, with DnaA boxes around 10000.
+func oriTestGenome() string {
+	random := rand.New(rand.NewSource(1))
+	buf := make([]byte, 20000)
+	for i := range buf {
+		bases := "AACCCGTT"
+		if i >= 10000 {
+			bases = "AACGGGTT"
+		}
+		buf[i] = bases[random.Intn(len(bases))]
+	}
+	for pos, box := range map[int]string{9900: "TTATCCACA", 9950: "TTATCCACA", 10050: "TGTGGATAA", 10100: "TTATCCAAA"} {
+		copy(buf[pos:], box)
+	}
+	return string(buf)
+}
+
+func TestPredictOri(t *testing.T) {
+	prediction := PredictOri(oriTestGenome(), DefaultOriOptions())
+
+	assert.Equal(t, 20000, prediction.GenomeLength)
+	assert.True(t, len(prediction.Windows) > 0)
+
+	best := prediction.Windows[0]
+	assert.True(t, best.Start <= 9900 && best.End >= 10109, "window %v-%v", best.Start, best.End)
+	assert.True(t, len(best.ConsensusHits) >= 4)
+	assert.Contains(t, best.ConsensusHits, Hit{Pos: 10050, Strand: Reverse, Mismatches: 0})
+	assert.Contains(t, best.ConsensusHits, Hit{Pos: 10100, Strand: Forward, Mismatches: 1})
+
+	box := best.Boxes[0]
+	assert.True(t, box.ConsensusDistance <= 1, "best box %v", box.Pattern)
+	assert.True(t, box.Count >= 4)
+}
+
+func TestPredictOri_linear(t *testing.T) {
+	opts := DefaultOriOptions()
+	opts.Topology = Linear
+	opts.WindowLength = 100
+
+	prediction := PredictOri(strings.Repeat("C", 50)+strings.Repeat("G", 50), opts)
+
+	assert.Len(t, prediction.Windows, 1)
+	assert.Equal(t, 0, prediction.Windows[0].Start)
+	assert.Equal(t, 100, prediction.Windows[0].End)
+	assert.Equal(t, 50, prediction.Windows[0].SkewPosition)
+}
+
+func TestPredictOri_minimaAroundOrigin(t *testing.T) {
+	// the skew is lowest at 5 and at 190, 15 bases apart across the origin
+	genome := strings.Repeat("C", 5) + strings.Repeat("G", 5) + strings.Repeat("A", 175) +
+		strings.Repeat("C", 5) + strings.Repeat("G", 5) + strings.Repeat("A", 5)
+	opts := DefaultOriOptions()
+	opts.WindowLength = 100
+
+	prediction := PredictOri(genome, opts)
+	assert.Len(t, prediction.Windows, 1)
+	assert.Equal(t, 5, prediction.Windows[0].SkewPosition)
+
+	opts.Topology = Linear
+	assert.Len(t, PredictOri(genome, opts).Windows, 2)
+}
+
+func TestPredictOriEmptyGenome(t *testing.T) {
+	for _, topology := range []Topology{Circular, Linear} {
+		opts := DefaultOriOptions()
+		opts.Topology = topology
+		prediction := PredictOri("", opts)
+		assert.Equal(t, 0, prediction.GenomeLength)
+		assert.Empty(t, prediction.Windows)
+	}
+}
+
+func TestOriOptionsValidate(t *testing.T) {
+	assert.NoError(t, DefaultOriOptions().validate())
+
+	opts := DefaultOriOptions()
+	opts.K = 0
+	assert.EqualError(t, opts.validate(), "k must be at least 1, got 0")
+
+	opts = DefaultOriOptions()
+	opts.WindowLength = -1
+	assert.EqualError(t, opts.validate(), "window length must be at least k (9), got -1")
+
+	opts = DefaultOriOptions()
+	opts.Mismatches = -1
+	assert.EqualError(t, opts.validate(), "mismatches must be at least 0, got -1")
+
+	opts = DefaultOriOptions()
+	opts.Candidates = -1
+	assert.EqualError(t, opts.validate(), "candidates must be at least 0, got -1")
+}
+
+func TestConsensusDistance(t *testing.T) {
+	assert.Equal(t, 0, consensusDistance("TTATCCACA", []string{"TTATCCACA"}))
+	assert.Equal(t, 0, consensusDistance("TGTGGATAA", []string{"TTATCCACA"}))
+	assert.Equal(t, 1, consensusDistance("TTATCCAAA", []string{"TTATCCACA"}))
+	assert.Equal(t, -1, consensusDistance("TTATCCAAA", []string{}))
+}
+
+func TestOriPredictionReport(t *testing.T) {
+	prediction := PredictOri(oriTestGenome(), DefaultOriOptions())
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, prediction.WriteJSON(&buf))
+	read := OriPrediction{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &read))
+	assert.Equal(t, prediction, read)
+
+	// the hits use the same key style as the rest of the report
+	var report struct {
+		Windows []struct {
+			Boxes []struct {
+				Hits []map[string]interface{} `json:"hits"`
+			} `json:"boxes"`
+		} `json:"windows"`
+	}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	hit := report.Windows[0].Boxes[0].Hits[0]
+	assert.Len(t, hit, 3)
+	assert.Contains(t, hit, "pos")
+	assert.Contains(t, hit, "strand")
+	assert.Contains(t, hit, "mismatches")
+	assert.Contains(t, []interface{}{"+", "-"}, hit["strand"])
+
+	buf.Reset()
+	assert.NoError(t, prediction.WriteHTML(&buf, "test genome"))
+	assert.Contains(t, buf.String(), "<h1>test genome</h1>")
+	assert.Contains(t, buf.String(), prediction.Windows[0].Boxes[0].Pattern)
+}
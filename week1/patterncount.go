@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"os"
 	"unicode/utf8"
 )
 
@@ -21,18 +23,7 @@ func SubStringPositionsAsString(dna, pattern string) string {
 }
 
 func SubStringPositions(dna, pattern string) []int {
-	positions := []int{}
-	lenText := utf8.RuneCountInString(dna)
-	lenPattern := utf8.RuneCountInString(pattern)
-
-	for i := 0; i <= lenText-lenPattern; i++ {
-		section := dna[i : i+lenPattern]
-		if section == pattern {
-			positions = append(positions, i)
-		}
-	}
-
-	return positions
+	return HitPositions(SubStringHits(dna, pattern, false))
 }
 
 func PatternCount(text, pattern string) int {
@@ -51,6 +42,12 @@ func PatternCount(text, pattern string) int {
 	return count
 }
 
+// PatternCountTopology counts pattern in text, including the occurrences spanning the end
+// of the sequence when the genome is circular.
+func PatternCountTopology(text, pattern string, topology Topology) int {
+	return PatternCount(topology.extendStr(text, len(pattern)-1), pattern)
+}
+
 type FreqWordResult struct {
 	RevPattern string
 	Pattern    string
@@ -164,6 +161,36 @@ func MovingWindowFrequentWords(dna string, kMer, windowLength, times int) map[st
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "ori":
+			err = oriCommand(os.Args[2:])
+		case "map":
+			err = mapCommand(os.Args[2:])
+		case "contigs":
+			err = contigsCommand(os.Args[2:])
+		case "qc":
+			err = qcCommand(os.Args[2:])
+		case "bin":
+			err = binCommand(os.Args[2:])
+		case "motifs":
+			err = motifsCommand(os.Args[2:])
+		case "scan":
+			err = scanCommand(os.Args[2:])
+		case "logo":
+			err = logoCommand(os.Args[2:])
+		case "compare":
+			err = compareCommand(os.Args[2:])
+		default:
+			err = fmt.Errorf("unknown command %v", os.Args[1])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	//pattern := "TGTGGAATG"
 	//count := PatternCount(text, pattern)
 	//fmt.Printf("pattern=%v count=%v\n", pattern, count)
@@ -220,3 +220,9 @@ const vibrioCholerae = `atcaatgatcaacgtaagcttctaagcatgatcaaggtgctcacacagtttatcca
 			tgataatgaatttacatgcttccgcgacgatttacctcttgatcatcgatccgattgaag
 			atcttcaattgttaattctcttgcctcgactcatagccatgatgagctcttgatcatgtt
 			tccttaaccctctattttttacggaagaatgatcaagctgctgctcttgatcatcgtttc`
+
+func TestPatternCountTopology(t *testing.T) {
+	assert.Equal(t, 0, PatternCountTopology("ACGGAT", "ATA", Linear))
+	assert.Equal(t, 1, PatternCountTopology("ACGGAT", "ATA", Circular))
+	assert.Equal(t, 6, PatternCountTopology("GACCATCAAAACTGATAAACTACTTAAAAATCAGT", "AAA", Circular))
+}
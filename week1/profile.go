@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// profileTolerance is how far from 1 a column of a profile may sum,
+// so profiles with probabilities rounded to a few decimals are accepted.
+const profileTolerance = 0.01
+
+// NewProfileMatrix makes a profile from data, with one row per base and one column per motif position.
+func NewProfileMatrix(data [][]float64) (ProfileMatrix, error) {
+	mat := ProfileMatrix{data: data}
+	if err := mat.Validate(); err != nil {
+		return ProfileMatrix{}, err
+	}
+	return mat, nil
+}
+
+/*
+   Profile(Motifs)
+       for each position i of the motifs
+           for each base b
+               Profile(b, i) ← (Count(b, i) + pseudocount) / (t + 4 · pseudocount)
+*/
+
+// NewProfileFromMotifs makes the profile of motifs, the frequency of each base at each position.
+// The pseudocount is added to every count, so with a positive pseudocount no base has probability 0
+// (Laplace's rule of succession for pseudocount 1).
+func NewProfileFromMotifs(motifs sequences, pseudocount float64) (ProfileMatrix, error) {
+	if err := validateMotifs(motifs); err != nil {
+		return ProfileMatrix{}, err
+	}
+	if pseudocount < 0 {
+		return ProfileMatrix{}, fmt.Errorf("pseudocount must be at least 0, got %v", pseudocount)
+	}
+
+	counts := Count(motifs)
+	total := float64(len(motifs)) + 4*pseudocount
+	data := make([][]float64, 4)
+	for nuc := range data {
+		data[nuc] = make([]float64, len(counts[nuc]))
+		for pos, count := range counts[nuc] {
+			data[nuc][pos] = (float64(count) + pseudocount) / total
+		}
+	}
+	return ProfileMatrix{data: data}, nil
+}
+
+func validateMotifs(motifs sequences) error {
+	if len(motifs) == 0 {
+		return errors.New("no motifs")
+	}
+	k := len(motifs[0])
+	if k == 0 {
+		return errors.New("motifs are empty")
+	}
+	for i, motif := range motifs {
+		if len(motif) != k {
+			return fmt.Errorf("motif %v has length %v, not %v", i, len(motif), k)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the profile has 4 rows of the same length, and that every column holds probabilities summing to 1.
+func (mat *ProfileMatrix) Validate() error {
+	if len(mat.data) != 4 {
+		return fmt.Errorf("profile must have 4 rows, got %v", len(mat.data))
+	}
+	k := len(mat.data[0])
+	if k == 0 {
+		return errors.New("profile has no columns")
+	}
+	for nuc, row := range mat.data {
+		if len(row) != k {
+			return fmt.Errorf("row %v has %v columns, not %v", nuc, len(row), k)
+		}
+	}
+	for pos := 0; pos < k; pos++ {
+		sum := 0.0
+		for nuc := range mat.data {
+			p := mat.data[nuc][pos]
+			if p < 0 || p > 1 || math.IsNaN(p) {
+				return fmt.Errorf("column %v has probability %v", pos, p)
+			}
+			sum += p
+		}
+		if math.Abs(sum-1) > profileTolerance {
+			return fmt.Errorf("column %v sums to %v, not 1", pos, sum)
+		}
+	}
+	return nil
+}
+
+// K is the length of the motif described by the profile.
+func (mat *ProfileMatrix) K() int {
+	if len(mat.data) == 0 {
+		return 0
+	}
+	return len(mat.data[0])
+}
+
+// Consensus is the most probable base at each position, the first in ACGT order on ties.
+func (mat *ProfileMatrix) Consensus() sequence {
+	consensus := make(sequence, mat.K())
+	for pos := range consensus {
+		for nuc := 1; nuc < 4; nuc++ {
+			if mat.Get(nuc, pos) > mat.Get(int(consensus[pos]), pos) {
+				consensus[pos] = byte(nuc)
+			}
+		}
+	}
+	return consensus
+}
+
+// Entropy is the sum of the entropy of each column in bits, from 0 for a fully conserved motif
+// to 2 bits per position for a uniform one.
+func (mat *ProfileMatrix) Entropy() float64 {
+	entropy := 0.0
+	for pos := 0; pos < mat.K(); pos++ {
+		for nuc := 0; nuc < 4; nuc++ {
+			if p := mat.Get(nuc, pos); p > 0 {
+				entropy -= p * math.Log2(p)
+			}
+		}
+	}
+	return entropy
+}
+
+// Count is the number of times each base occurs at each position of motifs, indexed [base][position].
+// The motifs must all have the same length.
+func Count(motifs sequences) [][]int {
+	k := 0
+	if len(motifs) > 0 {
+		k = len(motifs[0])
+	}
+	counts := make([][]int, 4)
+	for nuc := range counts {
+		counts[nuc] = make([]int, k)
+	}
+	for _, motif := range motifs {
+		for pos, nuc := range motif {
+			counts[nuc][pos]++
+		}
+	}
+	return counts
+}
+
+// Consensus is the most common base at each position of motifs, the first in ACGT order on ties.
+func Consensus(motifs sequences) sequence {
+	counts := Count(motifs)
+	consensus := make(sequence, len(counts[0]))
+	for pos := range consensus {
+		for nuc := 1; nuc < 4; nuc++ {
+			if counts[nuc][pos] > counts[consensus[pos]][pos] {
+				consensus[pos] = byte(nuc)
+			}
+		}
+	}
+	return consensus
+}
+
+// Score is the number of bases of motifs that differ from the consensus,
+// the sum of the Hamming distances from each motif to the consensus. Lower is better.
+func Score(motifs sequences) int {
+	if len(motifs) == 0 {
+		return 0
+	}
+	consensus := Consensus(motifs)
+	score := 0
+	for _, motif := range motifs {
+		score += HammingDistance(motif, consensus)
+	}
+	return score
+}
+
+// Entropy is the entropy in bits of the profile of motifs, without pseudocounts. Lower is better.
+// Unlike Score it tells a column split between two bases from one spread over all four.
+func Entropy(motifs sequences) float64 {
+	if len(motifs) == 0 {
+		return 0
+	}
+	profile, err := NewProfileFromMotifs(motifs, 0)
+	if err != nil {
+		return 0
+	}
+	return profile.Entropy()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var textbookMotifs = []string{
+	"TCGGGGGTTTTT",
+	"CCGGTGACTTAC",
+	"ACGGGGATTTTC",
+	"TTGGGGACTTTT",
+	"AAGGGGACTTCC",
+	"TTGGGGACTTCC",
+	"TCGGGGATTCAT",
+	"TCGGGGATTCCT",
+	"TAGGGGAACTAC",
+	"TCGGGTATAACC",
+}
+
+func TestCount(t *testing.T) {
+	counts := Count(NormalizeListDNA([]string{"ACG", "AGG", "TCG"}))
+	assert.Equal(t, [][]int{
+		{2, 0, 0}, // A
+		{0, 2, 0}, // C
+		{0, 1, 3}, // G
+		{1, 0, 0}, // T
+	}, counts)
+}
+
+func TestScoreAndConsensus(t *testing.T) {
+	motifs := NormalizeListDNA(textbookMotifs)
+
+	assert.Equal(t, "TCGGGGATTTCC", DeNormalizeDNA(Consensus(motifs)))
+	assert.Equal(t, 30, Score(motifs))
+	assert.InDelta(t, 9.916, Entropy(motifs), 1e-3)
+
+	assert.Equal(t, 0, Score(NormalizeListDNA([]string{"ACGT", "ACGT"})))
+	assert.Equal(t, 0.0, Entropy(NormalizeListDNA([]string{"ACGT", "ACGT"})))
+	assert.Equal(t, 0, Score(sequences{}))
+}
+
+func TestNewProfileFromMotifs(t *testing.T) {
+	motifs := NormalizeListDNA([]string{"ACG", "AGG", "TCG", "ACG"})
+
+	profile, err := NewProfileFromMotifs(motifs, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, profile.Validate())
+	assert.Equal(t, 3, profile.K())
+	assert.Equal(t, 0.75, profile.Get(0, 0))
+	assert.Equal(t, 0.25, profile.Get(3, 0))
+	assert.Equal(t, 0.0, profile.Get(1, 0))
+	assert.Equal(t, "ACG", DeNormalizeDNA(profile.Consensus()))
+
+	// Laplace's rule of succession
+	profile, err = NewProfileFromMotifs(motifs, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, profile.Validate())
+	assert.Equal(t, 4.0/8, profile.Get(0, 0))
+	assert.Equal(t, 1.0/8, profile.Get(1, 0))
+	assert.Equal(t, 5.0/8, profile.Get(2, 2))
+	assert.True(t, profile.Score(NormalizeDNA("TTT")) > 0)
+}
+
+func TestNewProfileFromMotifsInvalid(t *testing.T) {
+	_, err := NewProfileFromMotifs(sequences{}, 1)
+	assert.Error(t, err)
+	_, err = NewProfileFromMotifs(NormalizeListDNA([]string{"ACG", "AC"}), 1)
+	assert.Error(t, err)
+	_, err = NewProfileFromMotifs(NormalizeListDNA([]string{"ACG"}), -1)
+	assert.Error(t, err)
+}
+
+func TestNewProfileMatrix(t *testing.T) {
+	profile, err := NewProfileMatrix([][]float64{
+		{0.2, 0.2, 0.3, 0.2, 0.3}, // A
+		{0.4, 0.3, 0.1, 0.5, 0.1}, // C
+		{0.3, 0.3, 0.5, 0.2, 0.4}, // G
+		{0.1, 0.2, 0.1, 0.1, 0.2}, // T
+	})
+	assert.NoError(t, err)
+	// C and G tie at the second position
+	assert.Equal(t, "CCGCG", DeNormalizeDNA(profile.Consensus()))
+
+	_, err = NewProfileMatrix([][]float64{{1}, {0}, {0}})
+	assert.Error(t, err)
+	_, err = NewProfileMatrix([][]float64{{1, 0}, {0}, {0}, {0}})
+	assert.Error(t, err)
+	_, err = NewProfileMatrix([][]float64{{0.5}, {0.2}, {0.2}, {0.2}})
+	assert.Error(t, err)
+	_, err = NewProfileMatrix([][]float64{{1.5}, {-0.5}, {0}, {0}})
+	assert.Error(t, err)
+}
+
+func TestProfileEntropy(t *testing.T) {
+	uniform, err := NewProfileMatrix([][]float64{{0.25, 1}, {0.25, 0}, {0.25, 0}, {0.25, 0}})
+	assert.NoError(t, err)
+	assert.InDelta(t, 2, uniform.Entropy(), 1e-9)
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	// pwmMinProbability replaces profile and background probabilities of 0, so every k-mer has a finite score.
+	pwmMinProbability = 1e-4
+	// pwmResolution is the width in bits of the bins of the score distribution.
+	pwmResolution = 0.01
+)
+
+// PWM is a position weight matrix: the log-odds score in bits of each base at each position of a motif,
+// log2(profile probability / background probability). The score of a k-mer is the sum of the scores of its bases,
+// positive when it looks more like the motif than like the background.
+type PWM struct {
+	// weights[nuc][pos], like the data of ProfileMatrix
+	weights [][]float64
+	// background probability of each base
+	background []float64
+	// distribution[s] is the probability that a background k-mer scores (s+minBin)·pwmResolution bits
+	distribution []float64
+	minBin       int
+}
+
+// NewPWM makes the log-odds matrix of profile against the base frequencies of background.
+func NewPWM(profile ProfileMatrix, background Background) *PWM {
+	k := profile.K()
+	pwm := &PWM{weights: make([][]float64, 4), background: make([]float64, 4)}
+	for nuc := range pwm.weights {
+		pwm.background[nuc] = math.Max(background.WordProbability(sequence{byte(nuc)}), pwmMinProbability)
+		pwm.weights[nuc] = make([]float64, k)
+		for pos := 0; pos < k; pos++ {
+			p := math.Max(profile.Get(nuc, pos), pwmMinProbability)
+			pwm.weights[nuc][pos] = math.Log2(p / pwm.background[nuc])
+		}
+	}
+	pwm.computeDistribution()
+	return pwm
+}
+
+// K is the length of the motif.
+func (pwm *PWM) K() int {
+	return len(pwm.weights[0])
+}
+
+// Score is the log-odds score of kmer in bits.
+func (pwm *PWM) Score(kmer sequence) float64 {
+	score := 0.0
+	for pos, nuc := range kmer {
+		score += pwm.weights[nuc][pos]
+	}
+	return score
+}
+
+// MinScore and MaxScore are the lowest and highest scores of any k-mer.
+func (pwm *PWM) MinScore() float64 {
+	return pwm.extremeScore(math.Min)
+}
+
+func (pwm *PWM) MaxScore() float64 {
+	return pwm.extremeScore(math.Max)
+}
+
+func (pwm *PWM) extremeScore(pick func(x, y float64) float64) float64 {
+	score := 0.0
+	for pos := 0; pos < pwm.K(); pos++ {
+		best := pwm.weights[0][pos]
+		for nuc := 1; nuc < 4; nuc++ {
+			best = pick(best, pwm.weights[nuc][pos])
+		}
+		score += best
+	}
+	return score
+}
+
+// RelativeThreshold turns a fraction from 0 to 1 of the score range into a score,
+// so 0.8 means 80% of the way from the lowest to the highest score.
+func (pwm *PWM) RelativeThreshold(fraction float64) float64 {
+	min := pwm.MinScore()
+	return min + fraction*(pwm.MaxScore()-min)
+}
+
+/*
+   ScoreDistribution(PWM)
+       Distribution ← {0: 1}
+       for each position i
+           Next ← empty distribution
+           for each score s in Distribution, and each base b
+               Next(s + PWM(b, i)) += Distribution(s) · Background(b)
+           Distribution ← Next
+*/
+
+// computeDistribution finds the distribution of the scores of background k-mers by dynamic programming,
+// with the scores rounded to bins of pwmResolution bits.
+func (pwm *PWM) computeDistribution() {
+	k := pwm.K()
+	bins := make([][]int, 4)
+	minBin, maxBin := 0, 0
+	for nuc := range bins {
+		bins[nuc] = make([]int, k)
+		for pos := range bins[nuc] {
+			bins[nuc][pos] = int(math.Round(pwm.weights[nuc][pos] / pwmResolution))
+		}
+	}
+	for pos := 0; pos < k; pos++ {
+		low, high := bins[0][pos], bins[0][pos]
+		for nuc := 1; nuc < 4; nuc++ {
+			low, high = Min(low, bins[nuc][pos]), Max(high, bins[nuc][pos])
+		}
+		minBin += low
+		maxBin += high
+	}
+
+	// distribution[s] is the probability of score bin s+minBin over the positions so far.
+	// Every position has a weight of at most 0 and one of at least 0, so the partial sums stay in range.
+	distribution := make([]float64, maxBin-minBin+1)
+	next := make([]float64, len(distribution))
+	distribution[-minBin] = 1
+	for pos := 0; pos < k; pos++ {
+		for i := range next {
+			next[i] = 0
+		}
+		for s, p := range distribution {
+			if p == 0 {
+				continue
+			}
+			for nuc := 0; nuc < 4; nuc++ {
+				target := s + bins[nuc][pos]
+				if target >= 0 && target < len(next) {
+					next[target] += p * pwm.background[nuc]
+				}
+			}
+		}
+		distribution, next = next, distribution
+	}
+
+	pwm.distribution = distribution
+	pwm.minBin = minBin
+}
+
+// PValue is the probability that a k-mer drawn from the background scores at least score.
+// The distribution sums weights rounded to pwmResolution bits, so a k-mer score can be off by up to K·pwmResolution/2 bits;
+// PValue counts the k-mers that may score that much lower, and errs on the side of a higher p-value.
+func (pwm *PWM) PValue(score float64) float64 {
+	bin := pwm.pValueBin(score)
+	if bin <= 0 {
+		return 1
+	}
+	return pwm.tail(bin)
+}
+
+// pValueBin is the lowest bin of the distribution counted in the p-value of score.
+func (pwm *PWM) pValueBin(score float64) int {
+	return int(math.Ceil(score/pwmResolution-float64(pwm.K())/2)) - pwm.minBin
+}
+
+// tail is the probability of bin and every bin above it.
+func (pwm *PWM) tail(bin int) float64 {
+	p := 0.0
+	for s := len(pwm.distribution) - 1; s >= bin; s-- {
+		p += pwm.distribution[s]
+	}
+	return math.Min(p, 1)
+}
+
+// ThresholdForPValue is a score whose PValue, like that of every higher score, is at most pValue,
+// or a score above MaxScore when even the best k-mers are more likely than that.
+// It is halfway into the lowest bin PValue gives such a p-value, so the rounding of a score cannot move it to a lower bin.
+func (pwm *PWM) ThresholdForPValue(pValue float64) float64 {
+	if pValue >= 1 {
+		return pwm.MinScore()
+	}
+	bin := len(pwm.distribution)
+	for bin > 0 && pwm.tail(bin-1) <= pValue {
+		bin--
+	}
+	return (float64(bin+pwm.minBin) + float64(pwm.K())/2 - 0.5) * pwmResolution
+}
+
+// PWMHit is a k-mer scoring at least the threshold of a scan.
+// Pos is in forward-strand coordinates, like for Hit.
+type PWMHit struct {
+	Pos    int
+	Strand Strand
+	Score  float64
+	PValue float64
+}
+
+func (h PWMHit) String() string {
+	return fmt.Sprintf("%d%v(%.2f)", h.Pos, h.Strand, h.Score)
+}
+
+// Scan finds the k-mers of dna scoring at least threshold, sorted by position and strand.
+// With bothStrands the reverse complement of every k-mer is scored too, and reported on the reverse strand.
+func (pwm *PWM) Scan(dna sequence, threshold float64, bothStrands bool) []PWMHit {
+	hits := []PWMHit{}
+	k := pwm.K()
+	for i := 0; i <= len(dna)-k; i++ {
+		kmer := dna[i : i+k]
+		if score := pwm.Score(kmer); score >= threshold {
+			hits = append(hits, PWMHit{Pos: i, Strand: Forward, Score: score, PValue: pwm.PValue(score)})
+		}
+		if bothStrands {
+			if score := pwm.reverseScore(kmer); score >= threshold {
+				hits = append(hits, PWMHit{Pos: i, Strand: Reverse, Score: score, PValue: pwm.PValue(score)})
+			}
+		}
+	}
+	return hits
+}
+
+// reverseScore is the score of the reverse complement of kmer.
+func (pwm *PWM) reverseScore(kmer sequence) float64 {
+	k := len(kmer)
+	score := 0.0
+	for pos, nuc := range kmer {
+		score += pwm.weights[3-nuc][k-1-pos]
+	}
+	return score
+}
+
+// SortPWMHits sorts hits by score, best first, then by position and strand.
+func SortPWMHits(hits []PWMHit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Pos != b.Pos {
+			return a.Pos < b.Pos
+		}
+		return a.Strand < b.Strand
+	})
+}
+
+// WritePWMHits writes one line per hit, with the matching k-mer as it reads on its strand.
+func WritePWMHits(w io.Writer, dna sequence, k int, hits []PWMHit) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "pos\tstrand\tkmer\tscore\tp_value")
+	for _, hit := range hits {
+		kmer := dna[hit.Pos : hit.Pos+k]
+		if hit.Strand == Reverse {
+			kmer = RevComplement(kmer)
+		}
+		fmt.Fprintf(out, "%d\t%v\t%v\t%.3f\t%.3g\n", hit.Pos, hit.Strand, DeNormalizeDNA(kmer), hit.Score, hit.PValue)
+	}
+	return out.Flush()
+}
+
+// scanCommand scans a genome with a motif made from aligned sites or read from a motif file, and writes the hits to stdout, best first.
+// The threshold is the score giving the p-value given by -p, or a fraction of the score range given by -relative.
+//
+//	scan (-sites TTATCCACA,TTATCCAAA | -motif dnaa.jaspar [-id ID]) [-relative 0.8 | -p 1e-5] [-forward] genome.fasta
+func scanCommand(args []string) error {
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	sites := flags.String("sites", "", "comma separated aligned sites of the motif")
+	motifFile := flags.String("motif", "", "JASPAR, MEME or TRANSFAC file of the motif")
+	id := flags.String("id", "", "ID of the motif in the -motif file, the first one by default")
+	relative := flags.Float64("relative", 0, "report hits above this fraction of the score range")
+	pValue := flags.Float64("p", 1e-5, "report hits with at most this p-value, when -relative is not given")
+	forward := flags.Bool("forward", false, "only scan the forward strand")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || (*sites == "") == (*motifFile == "") {
+		return fmt.Errorf("usage: scan (-sites site,site... | -motif file) [flags] genome.fasta")
+	}
+
+	profile, err := scanProfile(*sites, *motifFile, *id)
+	if err != nil {
+		return err
+	}
+	genome, err := readGenome(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	normDNA := NormalizeDNA(strings.ToUpper(genome))
+
+	pwm := NewPWM(profile, NewMarkovModel(normDNA, 0))
+	threshold := pwm.ThresholdForPValue(*pValue)
+	if *relative > 0 {
+		threshold = pwm.RelativeThreshold(*relative)
+	}
+
+	hits := pwm.Scan(normDNA, threshold, !*forward)
+	SortPWMHits(hits)
+	return WritePWMHits(os.Stdout, normDNA, pwm.K(), hits)
+}
+
+// scanProfile is the profile of the sites, or of the motif with the ID in the motif file, with a pseudocount of 1.
+func scanProfile(sites, motifFile, id string) (ProfileMatrix, error) {
+	if sites != "" {
+		return NewProfileFromMotifs(NormalizeListDNA(strings.Split(strings.ToUpper(sites), ",")), 1)
+	}
+	motif, err := readMotif(motifFile, id)
+	if err != nil {
+		return ProfileMatrix{}, err
+	}
+	return motif.Profile(1)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var uniformBackground = NewMarkovModel(NormalizeDNA("ACGT"), 0)
+
+func newTestPWM(t *testing.T, sites ...string) *PWM {
+	profile, err := NewProfileFromMotifs(NormalizeListDNA(sites), 0)
+	assert.NoError(t, err)
+	return NewPWM(profile, uniformBackground)
+}
+
+func TestPWMScore(t *testing.T) {
+	pwm := newTestPWM(t, "AACC")
+
+	assert.Equal(t, 4, pwm.K())
+	assert.InDelta(t, 8, pwm.Score(NormalizeDNA("AACC")), 1e-9)
+	assert.InDelta(t, 6+math.Log2(pwmMinProbability/0.25), pwm.Score(NormalizeDNA("AACG")), 1e-9)
+	assert.InDelta(t, 8, pwm.MaxScore(), 1e-9)
+	assert.InDelta(t, 4*math.Log2(pwmMinProbability/0.25), pwm.MinScore(), 1e-9)
+	assert.InDelta(t, pwm.MinScore(), pwm.RelativeThreshold(0), 1e-9)
+	assert.InDelta(t, pwm.MaxScore(), pwm.RelativeThreshold(1), 1e-9)
+	assert.InDelta(t, (pwm.MinScore()+pwm.MaxScore())/2, pwm.RelativeThreshold(0.5), 1e-9)
+}
+
+func TestPWMMissingBackgroundBase(t *testing.T) {
+	profile, err := NewProfileFromMotifs(NormalizeListDNA([]string{"ACGT"}), 1)
+	assert.NoError(t, err)
+	pwm := NewPWM(profile, TrainMarkovModel(sequences{NormalizeDNA("AAAATTTT")}, 0, 0))
+
+	assert.False(t, math.IsInf(pwm.MaxScore(), 0))
+	// C is at position 1 in 2 of 5 pseudocounted sites, and never in the background
+	assert.InDelta(t, math.Log2(0.4/pwmMinProbability), pwm.weights[1][1], 1e-9)
+	assert.InDelta(t, 1.0, pwm.PValue(pwm.MinScore()), 1e-9)
+	assert.True(t, pwm.PValue(pwm.MaxScore()) > 0)
+}
+
+func TestPWMPValue(t *testing.T) {
+	pwm := newTestPWM(t, "AACC")
+
+	assert.InDelta(t, 1.0/256, pwm.PValue(8), 1e-12)
+	assert.InDelta(t, 1.0, pwm.PValue(pwm.MinScore()), 1e-9)
+	assert.Equal(t, 0.0, pwm.PValue(9))
+	// 1 of 256 4-mers matches all 4 bases, 12 more match 3 of them
+	assert.InDelta(t, 13.0/256, pwm.PValue(pwm.Score(NormalizeDNA("AACG"))), 1e-12)
+
+	// only AACC has a p-value of 1/256, the threshold is anywhere above the 3 base matches
+	threshold := pwm.ThresholdForPValue(1.0 / 256)
+	assert.True(t, threshold > pwm.Score(NormalizeDNA("AACG")) && threshold <= 8, "threshold %v", threshold)
+	threshold = pwm.ThresholdForPValue(0.06)
+	assert.True(t, threshold > pwm.Score(NormalizeDNA("AAGG")) && threshold <= pwm.Score(NormalizeDNA("AACG")), "threshold %v", threshold)
+	assert.True(t, pwm.ThresholdForPValue(1.0/512) > pwm.MaxScore())
+	assert.Equal(t, pwm.MinScore(), pwm.ThresholdForPValue(1))
+}
+
+func TestPWMThresholdForPValueHits(t *testing.T) {
+	profile, err := NewProfileFromMotifs(NormalizeListDNA([]string{"TTATCCACA", "TTATCCAAA", "TTTTCCACA", "GTATCAACA", "TTATACACA"}), 1)
+	assert.NoError(t, err)
+	dna := NormalizeDNA(randomizedMotifDataset[0] + randomizedMotifDataset[1] + "TTATCCACA" + randomizedMotifDataset[2] + "TTATACAAA")
+	pwm := NewPWM(profile, NewMarkovModel(dna, 0))
+
+	for _, p := range []float64{1e-5, 1e-3, 0.01, 0.05, 0.2, 0.5, 0.9} {
+		hits := pwm.Scan(dna, pwm.ThresholdForPValue(p), true)
+		for _, hit := range hits {
+			assert.True(t, hit.PValue <= p, "hit %v has p-value %v above %v", hit, hit.PValue, p)
+		}
+	}
+	assert.Len(t, pwm.Scan(dna, pwm.ThresholdForPValue(1), true), 2*(len(dna)-pwm.K()+1))
+}
+
+func TestPWMPValueMatchesEnumeration(t *testing.T) {
+	profile, err := NewProfileFromMotifs(NormalizeListDNA([]string{"TTATCCACA", "TTATCCAAA", "TTTTCCACA", "GTATCAACA", "TTATACACA"}), 1)
+	assert.NoError(t, err)
+	background := NewMarkovModel(NormalizeDNA("AATTTGCAT"), 0)
+	pwm := NewPWM(profile, background)
+
+	// the p-value of every score is bracketed by the exact p-values a few bins around it
+	k := pwm.K()
+	scores := make([]float64, Pow4(k))
+	probs := make([]float64, len(scores))
+	for i := range scores {
+		kmer := NormalizeDNA(IndexToPatternStr(k, i))
+		scores[i] = pwm.Score(kmer)
+		probs[i] = background.WordProbability(kmer)
+	}
+	exact := func(threshold float64) float64 {
+		p := 0.0
+		for i, score := range scores {
+			if score >= threshold {
+				p += probs[i]
+			}
+		}
+		return p
+	}
+	margin := float64(k) * pwmResolution
+	for _, threshold := range []float64{-20, -5, 0, 3, 6, 9, 12} {
+		p := pwm.PValue(threshold)
+		assert.True(t, p <= exact(threshold-margin)+1e-9, "p-value %v of %v above exact", p, threshold)
+		assert.True(t, p >= exact(threshold+margin)-1e-9, "p-value %v of %v below exact", p, threshold)
+	}
+}
+
+func TestPWMScan(t *testing.T) {
+	pwm := newTestPWM(t, "AACC")
+	dna := NormalizeDNA("TTAACCTTGGTTAACG")
+
+	hits := pwm.Scan(dna, pwm.MaxScore()-0.1, true)
+	assert.Equal(t, "[2+(8.00) 8-(8.00)]", fmt.Sprint(hits))
+	assert.InDelta(t, 1.0/256, hits[0].PValue, 1e-12)
+
+	hits = pwm.Scan(dna, pwm.MaxScore()-0.1, false)
+	assert.Equal(t, "[2+(8.00)]", fmt.Sprint(hits))
+
+	hits = pwm.Scan(dna, pwm.RelativeThreshold(0.6), false)
+	SortPWMHits(hits)
+	assert.Len(t, hits, 2)
+	assert.Equal(t, PWMHit{Pos: 2, Strand: Forward, Score: 8, PValue: hits[0].PValue}, hits[0])
+	assert.Equal(t, 12, hits[1].Pos)
+
+	assert.Empty(t, pwm.Scan(NormalizeDNA("AAC"), 0, true))
+}
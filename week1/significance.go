@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// WordStat tells how surprising the count of a word is under a background model.
+type WordStat struct {
+	Pattern  string
+	Count    int
+	Expected float64
+	Variance float64
+	ZScore   float64
+	// PValue is the probability of seeing at least Count occurrences.
+	PValue float64
+}
+
+func (w *WordStat) String() string {
+	return fmt.Sprintf("Pattern: %v Count: %v Expected: %.2f Z: %.2f P: %.3g", w.Pattern, w.Count, w.Expected, w.ZScore, w.PValue)
+}
+
+// ExpectedWordCount is the expected number of occurrences of word in a sequence of length dnaLen.
+func ExpectedWordCount(word sequence, dnaLen int, background Background) float64 {
+	positions := dnaLen - len(word) + 1
+	if positions <= 0 {
+		return 0
+	}
+	return float64(positions) * background.WordProbability(word)
+}
+
+/*
+   WordCountVariance: with N = dnaLen - k + 1 positions and p the probability of the word,
+   occurrences closer than k overlap, and are only possible when the shift d is a period of the word:
+       Var = N p (1 - p) + 2 * sum over d = 1..k-1 of (N - d) (period(d) * p * P(suffix of length d | word) - p^2)
+   Occurrences further apart are treated as independent, also for Markov backgrounds.
+*/
+
+// WordCountVariance is the variance of the number of occurrences of word in a sequence of length dnaLen,
+// taking into account that a self-overlapping word like ATATA tends to occur in clumps.
+func WordCountVariance(word sequence, dnaLen int, background Background) float64 {
+	k := len(word)
+	positions := dnaLen - k + 1
+	if positions <= 0 {
+		return 0
+	}
+
+	p := background.WordProbability(word)
+	variance := float64(positions) * p * (1 - p)
+
+	for d := 1; d < k && d < positions; d++ {
+		overlap := 0.0
+		if isPeriod(word, d) {
+			// probability of word shifted by d, given word: the last d bases following word
+			extended := make(sequence, k+d)
+			copy(extended, word)
+			copy(extended[k:], word[k-d:])
+			overlap = background.WordProbability(extended)
+		}
+		variance += 2 * float64(positions-d) * (overlap - p*p)
+	}
+
+	return variance
+}
+
+// isPeriod tells if word shifted by d overlaps itself.
+func isPeriod(word sequence, d int) bool {
+	for i := 0; i+d < len(word); i++ {
+		if word[i] != word[i+d] {
+			return false
+		}
+	}
+	return true
+}
+
+// NewWordStat computes the significance of seeing word count times in a sequence of length dnaLen.
+func NewWordStat(word sequence, count, dnaLen int, background Background) WordStat {
+	expected := ExpectedWordCount(word, dnaLen, background)
+	variance := WordCountVariance(word, dnaLen, background)
+
+	z := 0.0
+	if variance > 0 {
+		z = (float64(count) - expected) / math.Sqrt(variance)
+	}
+
+	return WordStat{
+		Pattern:  DeNormalizeDNA(word),
+		Count:    count,
+		Expected: expected,
+		Variance: variance,
+		ZScore:   z,
+		PValue:   PoissonUpperTail(count, expected),
+	}
+}
+
+// WordStatistics computes the significance of every k-mer found in normDNA, sorted by z-score.
+func WordStatistics(normDNA []byte, k int, background Background) []WordStat {
+	freqs := NewIndex(normDNA, k).Frequencies()
+
+	stats := []WordStat{}
+	for i := range freqs {
+		if freqs[i] > 0 {
+			word := NormalizeDNA(IndexToPatternStr(k, i))
+			stats = append(stats, NewWordStat(word, freqs[i], len(normDNA), background))
+		}
+	}
+
+	SortByZScore(stats)
+	return stats
+}
+
+// RankFreqWordResults computes the significance of results from FrequentWords or FasterFrequentWords
+// found in a sequence of length dnaLen, sorted by z-score.
+func RankFreqWordResults(results map[string]FreqWordResult, dnaLen int, background Background) []WordStat {
+	stats := make([]WordStat, 0, len(results))
+	for _, result := range results {
+		stats = append(stats, NewWordStat(NormalizeDNA(result.Pattern), result.Count, dnaLen, background))
+	}
+
+	SortByZScore(stats)
+	return stats
+}
+
+// SortByZScore sorts stats with the most over-represented word first.
+func SortByZScore(stats []WordStat) {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].ZScore != stats[j].ZScore {
+			return stats[i].ZScore > stats[j].ZScore
+		}
+		return stats[i].Pattern < stats[j].Pattern
+	})
+}
+
+// SortByPValue sorts stats with the most significant word first.
+func SortByPValue(stats []WordStat) {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].PValue != stats[j].PValue {
+			return stats[i].PValue < stats[j].PValue
+		}
+		return stats[i].Pattern < stats[j].Pattern
+	})
+}
+
+/*
+   ClumpPValue uses a Poisson clumping approximation. A window of length L has the L-k+1 positions
+   of the word, so its count is binomial. A clump starts where the k-mer entering the window is the
+   word and the rest of the window already has t-1 occurrences, which gives the expected number of
+   clumps in the genome:
+       mu = P(first window has >= t) + (dnaLen - L) * p * P(Bin(L-k, p) = t-1)
+   and the number of clumps is taken to be Poisson with mean mu.
+*/
+
+// ClumpPValue is the probability of word forming at least one (L, t)-clump in a random sequence of length dnaLen.
+func ClumpPValue(word sequence, dnaLen, windowLength, times int, background Background) float64 {
+	k := len(word)
+	if windowLength > dnaLen || k > windowLength {
+		return 1
+	}
+	p := background.WordProbability(word)
+	positions := windowLength - k + 1
+
+	mu := BinomialUpperTail(times, positions, p) +
+		float64(dnaLen-windowLength)*p*binomialProbability(times-1, positions-1, p)
+
+	return 1 - math.Exp(-mu)
+}
+
+// PoissonUpperTail is P(X >= count) for X Poisson distributed with mean lambda.
+func PoissonUpperTail(count int, lambda float64) float64 {
+	if count <= 0 {
+		return 1
+	}
+	if lambda <= 0 {
+		return 0
+	}
+
+	logTerm := func(i int) float64 {
+		lgamma, _ := math.Lgamma(float64(i + 1))
+		return float64(i)*math.Log(lambda) - lambda - lgamma
+	}
+
+	if float64(count) > lambda {
+		// the terms shrink from count and up, so sum the tail directly
+		sum := 0.0
+		term := math.Exp(logTerm(count))
+		for i := count; term > sum*1e-16; i++ {
+			sum += term
+			term = term * lambda / float64(i+1)
+		}
+		return math.Min(sum, 1)
+	}
+
+	sum := 0.0
+	for i := 0; i < count; i++ {
+		sum += math.Exp(logTerm(i))
+	}
+	return math.Max(1-sum, 0)
+}
+
+// BinomialUpperTail is P(X >= count) for X binomial distributed with n trials and probability p.
+func BinomialUpperTail(count, n int, p float64) float64 {
+	if count <= 0 {
+		return 1
+	}
+	sum := 0.0
+	for i := count; i <= n; i++ {
+		sum += binomialProbability(i, n, p)
+	}
+	return math.Min(sum, 1)
+}
+
+// binomialProbability is P(X = i) for X binomial distributed with n trials and probability p.
+func binomialProbability(i, n int, p float64) float64 {
+	if i < 0 || i > n {
+		return 0
+	}
+	if p <= 0 {
+		if i == 0 {
+			return 1
+		}
+		return 0
+	}
+	if p >= 1 {
+		if i == n {
+			return 1
+		}
+		return 0
+	}
+	lgN, _ := math.Lgamma(float64(n + 1))
+	lgI, _ := math.Lgamma(float64(i + 1))
+	lgNI, _ := math.Lgamma(float64(n - i + 1))
+
+	return math.Exp(lgN - lgI - lgNI + float64(i)*math.Log(p) + float64(n-i)*math.Log(1-p))
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var uniform = &MarkovModel{Order: 0, Initial: []float64{1}, Transitions: []float64{0.25, 0.25, 0.25, 0.25}}
+
+// exactWordCountMoments counts word in every sequence of length dnaLen
+func exactWordCountMoments(word string, dnaLen int) (mean, variance float64) {
+	total := int(Pow4(dnaLen))
+	sum, sumSquares := 0.0, 0.0
+	for i := 0; i < total; i++ {
+		count := float64(PatternCount(IndexToPatternStr(dnaLen, i), word))
+		sum += count
+		sumSquares += count * count
+	}
+	mean = sum / float64(total)
+	return mean, sumSquares/float64(total) - mean*mean
+}
+
+func TestWordCountVariance(t *testing.T) {
+	for _, word := range []string{"AAA", "ACG", "ATA", "ACA"} {
+		mean, variance := exactWordCountMoments(word, 7)
+
+		assert.InDelta(t, mean, ExpectedWordCount(NormalizeDNA(word), 7, uniform), 1e-9, word)
+		assert.InDelta(t, variance, WordCountVariance(NormalizeDNA(word), 7, uniform), 1e-9, word)
+	}
+
+	assert.True(t,
+		WordCountVariance(NormalizeDNA("AAAAAA"), 1000, uniform) > WordCountVariance(NormalizeDNA("ACGTTG"), 1000, uniform))
+}
+
+func TestIsPeriod(t *testing.T) {
+	assert.True(t, isPeriod(NormalizeDNA("ATATA"), 2))
+	assert.True(t, isPeriod(NormalizeDNA("ATATA"), 4))
+	assert.False(t, isPeriod(NormalizeDNA("ATATA"), 1))
+	assert.False(t, isPeriod(NormalizeDNA("ACGT"), 1))
+}
+
+func TestPoissonUpperTail(t *testing.T) {
+	assert.Equal(t, 1.0, PoissonUpperTail(0, 2))
+	assert.InDelta(t, 1-math.Exp(-1), PoissonUpperTail(1, 1), 1e-12)
+	assert.InDelta(t, 1-math.Exp(-0.5)*(1+0.5+0.125), PoissonUpperTail(3, 0.5), 1e-12)
+	assert.True(t, PoissonUpperTail(100, 1) > 0)
+	assert.True(t, PoissonUpperTail(100, 1) < 1e-100)
+}
+
+func TestBinomialUpperTail(t *testing.T) {
+	assert.InDelta(t, 0.75, BinomialUpperTail(1, 2, 0.5), 1e-12)
+	assert.InDelta(t, 0.125, BinomialUpperTail(3, 3, 0.5), 1e-12)
+	assert.Equal(t, 0.0, BinomialUpperTail(4, 3, 0.5))
+}
+
+func TestWordStatistics(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	buf := make([]byte, 3000)
+	for i := range buf {
+		buf[i] = "ACGT"[random.Intn(4)]
+	}
+	dna := string(buf)
+	for i := 0; i < 10; i++ {
+		pos := i * 300
+		dna = dna[:pos] + "GATTACAGG" + dna[pos+9:]
+	}
+	normDNA := NormalizeDNA(dna)
+
+	stats := WordStatistics(normDNA, 9, NewMarkovModel(normDNA, 1))
+
+	assert.Equal(t, "GATTACAGG", stats[0].Pattern)
+	assert.Equal(t, 10, stats[0].Count)
+	assert.True(t, stats[0].PValue < 1e-20)
+	for i := 1; i < len(stats); i++ {
+		assert.True(t, stats[i-1].ZScore >= stats[i].ZScore)
+	}
+}
+
+func TestRankFreqWordResults(t *testing.T) {
+	dna := strings.Repeat("AAAAAAAAAA", 5) + "ACGTTGCATGTCGCATGATGCATGAGAGCT"
+	normDNA := NormalizeDNA(dna)
+	results := NewIndex(normDNA, 4).Results(3)
+
+	stats := RankFreqWordResults(results, len(dna), NewMarkovModel(normDNA, 0))
+
+	// the rare words found 3 times are more over-represented than the poly-A run, though less significant
+	assert.Len(t, stats, 3)
+	assert.Equal(t, "CATG", stats[0].Pattern)
+	assert.Equal(t, "GCAT", stats[1].Pattern)
+	assert.Equal(t, 3, stats[0].Count)
+	assert.Equal(t, "AAAA", stats[2].Pattern)
+	assert.Equal(t, 48, stats[2].Count)
+
+	SortByPValue(stats)
+	assert.Equal(t, "AAAA", stats[0].Pattern)
+	for i := 1; i < len(stats); i++ {
+		assert.True(t, stats[i-1].PValue <= stats[i].PValue)
+	}
+}
+
+func TestClumpPValue(t *testing.T) {
+	word := NormalizeDNA("ACGTTGCAT")
+
+	few := ClumpPValue(word, 1000000, 500, 2, uniform)
+	many := ClumpPValue(word, 1000000, 500, 3, uniform)
+
+	assert.True(t, few > many)
+	assert.True(t, many > 0)
+	assert.True(t, few < 1)
+	assert.Equal(t, 1.0, ClumpPValue(word, 100, 500, 3, uniform))
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/gonum/plot"
 	"github.com/gonum/plot/plotter"
 	"github.com/gonum/plot/plotutil"
 	"github.com/gonum/plot/vg"
+	"github.com/gonum/plot/vg/draw"
 )
 
 // DNA is an uppercase string of ACGT
@@ -31,37 +36,148 @@ func MinSkew(dna []byte) (positions []int, value int) {
 	return Minimum(Skew(dna))
 }
 
+// MaxSkew finds the positions of maximum skew, where the terminus of replication is expected.
+func MaxSkew(dna []byte) (positions []int, value int) {
+	return MaximumPositions(Skew(dna))
+}
+
+// MinSkewTopology finds the positions of minimum skew.
+// On a circular genome position len(dna) is the same as position 0,
+// so positions are wrapped into [0, len(dna)) and reported once.
+func MinSkewTopology(dna []byte, topology Topology) (positions []int, value int) {
+	positions, value = MinSkew(dna)
+	if topology != Circular {
+		return positions, value
+	}
+
+	wrapped := make([]int, 0, len(positions))
+	seen := map[int]bool{}
+	for _, pos := range positions {
+		pos = topology.wrap(pos, len(dna))
+		if !seen[pos] {
+			seen[pos] = true
+			wrapped = append(wrapped, pos)
+		}
+	}
+	sort.Ints(wrapped)
+
+	return wrapped, value
+}
+
 func SkewPlot(title, filename, dna string) ([]int, error) {
 	start := time.Now()
-	skewData := SkewStr(dna)
+	normDNA := NormalizeDNA(dna)
+	skewData := Skew(normDNA)
 	log.Print("skewData done in ", time.Since(start).String())
 
-	start = time.Now()
-	plotPoints := createPlotPoints(skewData)
+	series := []SkewSeries{{Name: title, DNA: normDNA}}
+	if err := SkewPlotSeries(title, filename, series, DefaultSkewPlotOptions()); err != nil {
+		return nil, err
+	}
+
+	return skewData, nil
+}
+
+// SkewSeries is one genome or contig in a skew plot.
+type SkewSeries struct {
+	Name string
+	DNA  []byte
+}
+
+// SkewPlotOptions configures SkewPlotSeries.
+type SkewPlotOptions struct {
+	// MaxPoints is the most points drawn per series. Longer series are split in bins,
+	// and the minimum and maximum of each bin are drawn, so the peaks are kept.
+	MaxPoints int
+	// Markers marks the positions of minimum skew (origin) and maximum skew (terminus).
+	Markers bool
+	Width   vg.Length
+	Height  vg.Length
+}
+
+func DefaultSkewPlotOptions() SkewPlotOptions {
+	return SkewPlotOptions{
+		MaxPoints: 4000,
+		Markers:   true,
+		Width:     30 * vg.Centimeter,
+		Height:    20 * vg.Centimeter,
+	}
+}
+
+var skewPlotFormats = map[string]bool{
+	".eps":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".pdf":  true,
+	".png":  true,
+	".svg":  true,
+	".tif":  true,
+	".tiff": true,
+}
+
+// SkewPlotSeries plots the skew of one or more genomes or contigs on top of each other.
+// The format is chosen by the extension of filename, like .svg, .pdf or .png.
+func SkewPlotSeries(title, filename string, series []SkewSeries, opts SkewPlotOptions) error {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !skewPlotFormats[ext] {
+		return fmt.Errorf("unsupported plot format %q", ext)
+	}
+
+	start := time.Now()
 	p, err := plot.New()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	p.Title.Text = title + " Skew(G-C) plot"
 	p.X.Label.Text = "bp"
 	p.Y.Label.Text = "G-C (Y)"
 
-	err = plotutil.AddLinePoints(p,
-		title, plotPoints)
-	if err != nil {
-		return nil, err
+	for i, s := range series {
+		skewData := Skew(s.DNA)
+
+		line, err := plotter.NewLine(downsamplePlotPoints(skewData, opts.MaxPoints))
+		if err != nil {
+			return err
+		}
+		line.LineStyle.Color = plotutil.Color(i)
+		p.Add(line)
+		p.Legend.Add(s.Name, line)
+
+		if !opts.Markers {
+			continue
+		}
+
+		origin, _ := Minimum(skewData)
+		terminus, _ := MaximumPositions(skewData)
+		for _, marker := range []struct {
+			name      string
+			positions []int
+			shape     draw.GlyphDrawer
+		}{
+			{"ori", origin, draw.TriangleGlyph{}},
+			{"ter", terminus, draw.CrossGlyph{}},
+		} {
+			scatter, err := plotter.NewScatter(markerPlotPoints(skewData, marker.positions))
+			if err != nil {
+				return err
+			}
+			scatter.GlyphStyle.Color = plotutil.Color(i)
+			scatter.GlyphStyle.Shape = marker.shape
+			scatter.GlyphStyle.Radius = vg.Points(4)
+			p.Add(scatter)
+			p.Legend.Add(s.Name+" "+marker.name, scatter)
+		}
 	}
 	log.Print("plotting done in ", time.Since(start).String())
 
-	// Save the plot to a PNG file.
 	start = time.Now()
-	if err := p.Save(30*vg.Centimeter, 20*vg.Centimeter, filename); err != nil {
-		return nil, err
+	if err := p.Save(opts.Width, opts.Height, filename); err != nil {
+		return err
 	}
 	log.Print("saving plot done in ", time.Since(start).String())
 
-	return skewData, nil
+	return nil
 }
 
 func createPlotPoints(data []int) plotter.XYs {
@@ -73,6 +189,46 @@ func createPlotPoints(data []int) plotter.XYs {
 	return pts
 }
 
+// downsamplePlotPoints keeps the minimum and maximum of each bin, in the order they appear,
+// so that data is drawn with at most maxPoints points without losing its peaks.
+func downsamplePlotPoints(data []int, maxPoints int) plotter.XYs {
+	if maxPoints < 2 || len(data) <= maxPoints {
+		return createPlotPoints(data)
+	}
+
+	binSize := (2*len(data) + maxPoints - 1) / maxPoints
+	pts := make(plotter.XYs, 0, maxPoints)
+	for start := 0; start < len(data); start += binSize {
+		end := Min(start+binSize, len(data))
+
+		minIdx, maxIdx := start, start
+		for i := start; i < end; i++ {
+			if data[i] < data[minIdx] {
+				minIdx = i
+			}
+			if data[i] > data[maxIdx] {
+				maxIdx = i
+			}
+		}
+
+		first, second := Min(minIdx, maxIdx), Max(minIdx, maxIdx)
+		pts = append(pts, struct{ X, Y float64 }{float64(first), float64(data[first])})
+		if second != first {
+			pts = append(pts, struct{ X, Y float64 }{float64(second), float64(data[second])})
+		}
+	}
+	return pts
+}
+
+func markerPlotPoints(data []int, positions []int) plotter.XYs {
+	pts := make(plotter.XYs, len(positions))
+	for i, pos := range positions {
+		pts[i].X = float64(pos)
+		pts[i].Y = float64(data[pos])
+	}
+	return pts
+}
+
 var skewValues = []int{
 	0: 0,  // A
 	1: -1, // C
@@ -2,6 +2,8 @@ package main
 
 import (
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"fmt"
@@ -89,3 +91,46 @@ func TestSkewPlotThermotoga(t *testing.T) {
 		panic(err)
 	}
 }
+
+func TestMinSkewTopology(t *testing.T) {
+	pos, val := MinSkewTopology(NormalizeDNA("CCGGCC"), Linear)
+	assert.Equal(t, []int{2, 6}, pos)
+	assert.Equal(t, -2, val)
+
+	pos, val = MinSkewTopology(NormalizeDNA("CCGGCC"), Circular)
+	assert.Equal(t, []int{0, 2}, pos)
+	assert.Equal(t, -2, val)
+
+	pos, _ = MinSkewTopology(NormalizeDNA("CCC"), Circular)
+	assert.Equal(t, []int{0}, pos)
+}
+
+func TestDownsamplePlotPoints(t *testing.T) {
+	data := []int{0, 1, 2, 3, 2, 1, 0, -1, -2, -1}
+
+	assert.Equal(t, createPlotPoints(data), downsamplePlotPoints(data, 20))
+
+	pts := downsamplePlotPoints(data, 4)
+	assert.Len(t, pts, 4)
+	assert.Equal(t, 0.0, pts[0].X)
+	assert.Equal(t, 3.0, pts[1].X)
+	assert.Equal(t, 3.0, pts[1].Y)
+	assert.Equal(t, 8.0, pts[3].X)
+	assert.Equal(t, -2.0, pts[3].Y)
+}
+
+func TestSkewPlotSeries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skewplot")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	series := []SkewSeries{
+		{Name: "first", DNA: NormalizeDNA("TAAAGACTGCCGAGAGGCCAACACGAGTGCTAGAACGAGGGGCGTAAACGCGGGTCCGAT")},
+		{Name: "second", DNA: NormalizeDNA("CATTCCAGTACTTCGATGATGGCGTGAAGA")},
+	}
+	for _, name := range []string{"skew.svg", "skew.pdf", "skew.png"} {
+		assert.NoError(t, SkewPlotSeries("test", filepath.Join(dir, name), series, DefaultSkewPlotOptions()))
+	}
+
+	assert.Error(t, SkewPlotSeries("test", filepath.Join(dir, "skew.txt"), series, DefaultSkewPlotOptions()))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -8,6 +9,8 @@ import (
 type Index struct {
 	k     int
 	freqs []int
+	// counts of the reverse complement, only set when counting both strands
+	revFreqs []int
 }
 
 func NewIndexStr(dna string, k int) *Index {
@@ -24,26 +27,98 @@ func NewIndex(normDNA []byte, k int) *Index {
 	return &idx
 }
 
+// NewIndexWithMismatches counts for every k-mer the k-mers in normDNA with at most d mismatches to it.
+// With bothStrands, the approximate occurrences of the reverse complement are counted as RevCount.
+func NewIndexWithMismatches(normDNA []byte, k, d int, bothStrands bool) *Index {
+	idx := Index{
+		k:     k,
+		freqs: createKmerArray(k),
+	}
+	if bothStrands {
+		idx.revFreqs = createKmerArray(k)
+	}
+
+	for i := 0; i <= len(normDNA)-k; i++ {
+		kmer := normDNA[i : i+k]
+		for _, neighbor := range NeighborsSimple(kmer, d) {
+			idx.freqs[PatternToIndex(neighbor)]++
+		}
+		if bothStrands {
+			for _, neighbor := range NeighborsSimple(RevComplement(kmer), d) {
+				idx.revFreqs[PatternToIndex(neighbor)]++
+			}
+		}
+	}
+	return &idx
+}
+
 func (idx *Index) Frequencies() []int {
 	return idx.freqs
 }
 
 func (idx *Index) Results(times int) map[string]FreqWordResult {
 	data := map[string]FreqWordResult{}
-	for i := 0; i < len(idx.freqs); i++ {
-		count := idx.freqs[i]
-		if i >= times {
-			dnaStr := IndexToPatternStr(idx.k, i)
-			data[dnaStr] = FreqWordResult{
-				Pattern: dnaStr,
-				Count:   count,
-			}
-		}
+	for _, result := range idx.AtLeast(times) {
+		data[result.Pattern] = result
 	}
 
 	return data
 }
 
+// Sorted returns all k-mers found, with the most frequent first.
+// Ties are sorted alphabetically, so the order is always the same.
+func (idx *Index) Sorted() []FreqWordResult {
+	return idx.AtLeast(1)
+}
+
+// TopN returns the n most frequent k-mers, sorted like Sorted, or none when n is negative.
+func (idx *Index) TopN(n int) []FreqWordResult {
+	sorted := idx.Sorted()
+	return sorted[:Max(Min(n, len(sorted)), 0)]
+}
+
+// AtLeast returns the k-mers found at least count times, sorted like Sorted.
+func (idx *Index) AtLeast(count int) []FreqWordResult {
+	indexes := []int{}
+	for i := range idx.freqs {
+		if idx.total(i) >= count {
+			indexes = append(indexes, i)
+		}
+	}
+
+	// indexes are in alphabetical order of their pattern, keep it for ties
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return idx.total(indexes[a]) > idx.total(indexes[b])
+	})
+
+	results := make([]FreqWordResult, len(indexes))
+	for i, kmerIdx := range indexes {
+		results[i] = idx.result(kmerIdx)
+	}
+	return results
+}
+
+// total is the count of the k-mer on both strands.
+func (idx *Index) total(kmerIdx int) int {
+	if idx.revFreqs == nil {
+		return idx.freqs[kmerIdx]
+	}
+	return idx.freqs[kmerIdx] + idx.revFreqs[kmerIdx]
+}
+
+func (idx *Index) result(kmerIdx int) FreqWordResult {
+	pattern := IndexToPatternStr(idx.k, kmerIdx)
+	result := FreqWordResult{
+		Pattern: pattern,
+		Count:   idx.freqs[kmerIdx],
+	}
+	if idx.revFreqs != nil {
+		result.RevPattern = RevComplementStr(pattern)
+		result.RevCount = idx.revFreqs[kmerIdx]
+	}
+	return result
+}
+
 func computeFrequencies(k int, normDNA []byte, kmerArray []int) []int {
 	dnaLen := len(normDNA)
 
@@ -104,7 +179,17 @@ func FasterFrequentWords(normDNA []byte, k int) map[string]FreqWordResult {
        return FrequentPatterns
 */
 func MovingWindowFrequentWordsFaster(dna string, kMer, windowLength, times int) map[string]FreqWordResult {
-	normDNA := NormalizeDNA(dna)
+	return movingWindowFrequentWords(NormalizeDNA(dna), kMer, windowLength, times)
+}
+
+// MovingWindowFrequentWordsTopology finds clumps like MovingWindowFrequentWordsFaster.
+// For a circular genome the windows wrap around the end, so clumps spanning the origin of the sequence are found.
+func MovingWindowFrequentWordsTopology(dna string, kMer, windowLength, times int, topology Topology) map[string]FreqWordResult {
+	normDNA := topology.extend(NormalizeDNA(dna), windowLength-1)
+	return movingWindowFrequentWords(normDNA, kMer, windowLength, times)
+}
+
+func movingWindowFrequentWords(normDNA []byte, kMer, windowLength, times int) map[string]FreqWordResult {
 	dnaLen := len(normDNA)
 
 	wanted := map[string]FreqWordResult{}
@@ -122,7 +207,7 @@ func MovingWindowFrequentWordsFaster(dna string, kMer, windowLength, times int)
 		}
 	}
 
-	for i := 1; i <= dnaLen-windowLength-1; i++ {
+	for i := 1; i <= dnaLen-windowLength; i++ {
 		// remove the window we are moving out of
 		firstPat := normDNA[i-1 : i-1+kMer]
 		firstIdx := PatternToIndex(firstPat)
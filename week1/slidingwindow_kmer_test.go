@@ -69,6 +69,17 @@ func TestMovingWindowFrequentWords_e_coli(t *testing.T) {
 	//fmt.Printf("MovingWindowFrequentWords(dna, 11, 566, 18): %+v\n", results)
 }
 
+func TestMovingWindowFrequentWordsTopology(t *testing.T) {
+	dna := "AGCTTGCAA"
+
+	results := MovingWindowFrequentWordsTopology(dna, 2, 4, 2, Linear)
+	assert.NotContains(t, results, "AA")
+
+	results = MovingWindowFrequentWordsTopology(dna, 2, 4, 2, Circular)
+	assert.Contains(t, results, "AA")
+	assert.Equal(t, 2, results["AA"].Count)
+}
+
 func TestFasterFrequentWords(t *testing.T) {
 	wanted := []FreqWordResult{
 		{Pattern: "CATG", Count: 3},
@@ -92,6 +103,53 @@ func TestFrequencyCounting(t *testing.T) {
 	assert.Equal(t, expected, idx.Frequencies())
 }
 
+func TestIndexResults(t *testing.T) {
+	idx := NewIndexStr("ACGCGGCTCTGAAA", 2)
+
+	results := idx.Results(2)
+	assert.Len(t, results, 4)
+	assert.Equal(t, FreqWordResult{Pattern: "CT", Count: 2}, results["CT"])
+	assert.NotContains(t, results, "AC")
+}
+
+func TestIndexTopN(t *testing.T) {
+	idx := NewIndexStr("ACGCGGCTCTGAAA", 2)
+
+	patterns := []string{}
+	for _, result := range idx.Sorted() {
+		patterns = append(patterns, result.Pattern)
+	}
+	assert.Equal(t, []string{"AA", "CG", "CT", "GC", "AC", "GA", "GG", "TC", "TG"}, patterns)
+
+	assert.Equal(t, []FreqWordResult{
+		{Pattern: "AA", Count: 2},
+		{Pattern: "CG", Count: 2},
+		{Pattern: "CT", Count: 2},
+	}, idx.TopN(3))
+	assert.Len(t, idx.TopN(20), 9)
+	assert.Empty(t, idx.TopN(-1))
+	assert.Len(t, idx.AtLeast(2), 4)
+}
+
+func TestIndexWithMismatches(t *testing.T) {
+	dna := NormalizeDNA("ACGTTGCATGTCGCATGATGCATGAGAGCT")
+
+	assert.Equal(t, []FreqWordResult{
+		{Pattern: "ATGC", Count: 5},
+		{Pattern: "ATGT", Count: 5},
+		{Pattern: "GATG", Count: 5},
+	}, NewIndexWithMismatches(dna, 4, 1, false).AtLeast(5))
+
+	top := NewIndexWithMismatches(dna, 4, 1, true).TopN(2)
+	assert.Equal(t, "ACAT", top[0].Pattern)
+	assert.Equal(t, "ATGT", top[1].Pattern)
+	assert.Equal(t, 9, top[0].Count+top[0].RevCount)
+	assert.Equal(t, 9, top[1].Count+top[1].RevCount)
+
+	exact := NewIndexWithMismatches(dna, 4, 0, false)
+	assert.Equal(t, NewIndex(dna, 4).Sorted(), exact.Sorted())
+}
+
 func TestFrequencyCounting_big(t *testing.T) {
 	data, _ := ioutil.ReadFile("dataset_2994_5.txt")
 	dna := string(data)
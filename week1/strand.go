@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Strand tells which strand of the DNA a hit was found on.
+type Strand int
+
+const (
+	Forward Strand = iota
+	Reverse
+)
+
+func (s Strand) String() string {
+	if s == Reverse {
+		return "-"
+	}
+	return "+"
+}
+
+func (s Strand) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Strand) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "+":
+		*s = Forward
+	case "-":
+		*s = Reverse
+	default:
+		return fmt.Errorf("unknown strand %q", text)
+	}
+	return nil
+}
+
+// Hit is a match of a pattern in a genome.
+// Pos is always given in forward-strand coordinates, as the leftmost base of the match,
+// also for hits on the reverse strand.
+type Hit struct {
+	Pos        int    `json:"pos"`
+	Strand     Strand `json:"strand"`
+	Mismatches int    `json:"mismatches"`
+}
+
+func (h Hit) String() string {
+	return fmt.Sprintf("%d%v(%d)", h.Pos, h.Strand, h.Mismatches)
+}
+
+// SubStringHits returns all exact occurrences of pattern in dna.
+// With bothStrands, occurrences of the reverse complement are reported as hits on the reverse strand.
+func SubStringHits(dna, pattern string, bothStrands bool) []Hit {
+	return ApproximateSubStringHits(dna, pattern, 0, bothStrands)
+}
+
+// ApproximateSubStringHits returns all occurrences of pattern in text with at most distance mismatches.
+// With bothStrands, the reverse complement of pattern is searched as well.
+// A palindromic pattern is its own reverse complement, so it is only reported on the forward strand.
+func ApproximateSubStringHits(text, pattern string, distance int, bothStrands bool) []Hit {
+	hits := approximateHits(text, pattern, distance, Forward)
+
+	if bothStrands {
+		revPattern := RevComplementStr(pattern)
+		if revPattern != pattern {
+			hits = append(hits, approximateHits(text, revPattern, distance, Reverse)...)
+			sortHits(hits)
+		}
+	}
+
+	return hits
+}
+
+// PatternCountStrands counts the occurrences of pattern in text, optionally on both strands.
+func PatternCountStrands(text, pattern string, bothStrands bool) int {
+	return len(SubStringHits(text, pattern, bothStrands))
+}
+
+// HitPositions returns the positions of hits, in the same order.
+func HitPositions(hits []Hit) []int {
+	positions := make([]int, len(hits))
+	for i := range hits {
+		positions[i] = hits[i].Pos
+	}
+	return positions
+}
+
+func approximateHits(text, pattern string, distance int, strand Strand) []Hit {
+	hits := make([]Hit, 0, 5)
+	patLen := len(pattern)
+
+	for i := 0; i <= len(text)-patLen; i++ {
+		window := text[i : i+patLen]
+		mismatches := 0
+		if distance > 0 {
+			mismatches = HammingDistanceStr(window, pattern)
+		} else if window != pattern {
+			continue
+		}
+		if mismatches <= distance {
+			hits = append(hits, Hit{Pos: i, Strand: strand, Mismatches: mismatches})
+		}
+	}
+
+	return hits
+}
+
+func sortHits(hits []Hit) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Pos != hits[j].Pos {
+			return hits[i].Pos < hits[j].Pos
+		}
+		return hits[i].Strand < hits[j].Strand
+	})
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubStringHits(t *testing.T) {
+	assert.Equal(t,
+		[]Hit{{Pos: 9, Strand: Forward}},
+		SubStringHits("ACGTTTACGAAA", "AAA", false))
+
+	assert.Equal(t,
+		[]Hit{{Pos: 3, Strand: Reverse}, {Pos: 9, Strand: Forward}},
+		SubStringHits("ACGTTTACGAAA", "AAA", true))
+
+	// palindromes are only reported once
+	assert.Equal(t,
+		[]Hit{{Pos: 1, Strand: Forward}, {Pos: 3, Strand: Forward}, {Pos: 9, Strand: Forward}},
+		SubStringHits("GATATATGCATATACTT", "ATAT", true))
+}
+
+func TestApproximateSubStringHits(t *testing.T) {
+	assert.Equal(t,
+		[]Hit{{Pos: 0, Strand: Forward, Mismatches: 0}, {Pos: 2, Strand: Reverse, Mismatches: 1}},
+		ApproximateSubStringHits("CCAGGA", "CCA", 1, true))
+
+	assert.Equal(t,
+		[]int{6, 7, 26, 27, 66, 69},
+		HitPositions(ApproximateSubStringHits("CGCCCGAATCCAGAACGCATTCCCATATTTCGGGACCACTGGCCTCCACGGTACGGACGTCAATCAAATATTGAGGA", "ATTCTGGA", 3, false)))
+}
+
+func TestPatternCountStrands(t *testing.T) {
+	assert.Equal(t, 6, PatternCountStrands("GACCATCAAAACTGATAAACTACTTAAAAATCAGT", "AAA", false))
+	assert.Equal(t, 1, PatternCountStrands("ACGTTTACGAAA", "AAA", false))
+	assert.Equal(t, 2, PatternCountStrands("ACGTTTACGAAA", "AAA", true))
+}
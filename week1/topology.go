@@ -0,0 +1,61 @@
+package main
+
+// Topology describes if a genome is linear or circular.
+// Bacterial chromosomes and plasmids are usually circular, so k-mers and windows
+// may span the end of the sequence and continue at the start.
+type Topology int
+
+const (
+	Linear Topology = iota
+	Circular
+)
+
+func (t Topology) String() string {
+	if t == Circular {
+		return "circular"
+	}
+	return "linear"
+}
+
+// extend returns dna with the first overlap bases appended when the topology is circular,
+// so that every k-mer or window starting in dna can be read without wrapping.
+func (t Topology) extend(dna []byte, overlap int) []byte {
+	if t != Circular || overlap <= 0 {
+		return dna
+	}
+	overlap = Min(overlap, len(dna))
+
+	buf := make([]byte, len(dna)+overlap)
+	copy(buf, dna)
+	copy(buf[len(dna):], dna[:overlap])
+	return buf
+}
+
+func (t Topology) extendStr(dna string, overlap int) string {
+	if t != Circular || overlap <= 0 {
+		return dna
+	}
+	overlap = Min(overlap, len(dna))
+	return dna + dna[:overlap]
+}
+
+// wrap maps a position in the extended sequence back onto a genome of length n.
+func (t Topology) wrap(pos, n int) int {
+	if t != Circular || n == 0 {
+		return pos
+	}
+	return pos % n
+}
+
+// distance is the number of bases between positions a and b of a genome of length n,
+// the shorter way round when the topology is circular.
+func (t Topology) distance(a, b, n int) int {
+	d := a - b
+	if d < 0 {
+		d = -d
+	}
+	if t == Circular {
+		d = Min(d, n-d)
+	}
+	return d
+}
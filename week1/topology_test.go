@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopologyExtend(t *testing.T) {
+	assert.Equal(t, "ACGTA", DeNormalizeDNA(Linear.extend(NormalizeDNA("ACGTA"), 2)))
+	assert.Equal(t, "ACGTAAC", DeNormalizeDNA(Circular.extend(NormalizeDNA("ACGTA"), 2)))
+	assert.Equal(t, "ACGACG", DeNormalizeDNA(Circular.extend(NormalizeDNA("ACG"), 5)))
+	assert.Equal(t, "ACGTAAC", Circular.extendStr("ACGTA", 2))
+}
+
+func TestTopologyWrap(t *testing.T) {
+	assert.Equal(t, 7, Linear.wrap(7, 5))
+	assert.Equal(t, 2, Circular.wrap(7, 5))
+	assert.Equal(t, 0, Circular.wrap(5, 5))
+}
+
+func TestTopologyDistance(t *testing.T) {
+	assert.Equal(t, 15, Linear.distance(5, 20, 25))
+	assert.Equal(t, 10, Circular.distance(5, 20, 25))
+	assert.Equal(t, 10, Circular.distance(20, 5, 25))
+	assert.Equal(t, 3, Circular.distance(2, 5, 25))
+}
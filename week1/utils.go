@@ -1,8 +1,12 @@
 package main
 
 import (
+	"bufio"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
 	"strings"
 )
 
@@ -18,6 +22,11 @@ func (f *Fasta) Genome() string {
 	return f.genome
 }
 
+// Name is the header without the leading '>'.
+func (f *Fasta) Name() string {
+	return strings.TrimSpace(strings.TrimPrefix(f.rawHeader, ">"))
+}
+
 func ReadFasta(filename string) (Fasta, error) {
 	data, err := ioutil.ReadFile(filename)
 	if err != nil {
@@ -40,6 +49,68 @@ func ReadFasta(filename string) (Fasta, error) {
 	return fasta, nil
 }
 
+// ReadFastaRecords reads every record of a FASTA file, where ReadFasta reads the whole file as one record.
+func ReadFastaRecords(filename string) ([]Fasta, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseFasta(file)
+}
+
+// ParseFasta reads FASTA records from r, joining the sequence lines of each record.
+func ParseFasta(r io.Reader) ([]Fasta, error) {
+	records := []Fasta{}
+	genome := []string{}
+	endRecord := func() {
+		if len(records) > 0 {
+			records[len(records)-1].genome = strings.Join(genome, "")
+		}
+		genome = genome[:0]
+	}
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if strings.HasPrefix(line, ">") {
+			endRecord()
+			records = append(records, Fasta{rawHeader: line})
+		} else if line = strings.TrimSpace(line); line != "" {
+			if len(records) == 0 {
+				return nil, errors.New("sequence before the first FASTA header")
+			}
+			genome = append(genome, line)
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+	endRecord()
+
+	return records, nil
+}
+
+// WriteFasta writes records as FASTA, with the sequence in lines of 60 bases.
+func WriteFasta(w io.Writer, records []Fasta) error {
+	out := bufio.NewWriter(w)
+	for i := range records {
+		fmt.Fprintf(out, ">%v\n", records[i].Name())
+		genome := records[i].Genome()
+		for start := 0; start < len(genome); start += 60 {
+			fmt.Fprintln(out, genome[start:Min(start+60, len(genome))])
+		}
+	}
+	return out.Flush()
+}
+
 // Integer power: compute a**b using binary powering algorithm
 // See Donald Knuth, The Art of Computer Programming, Volume 2, Section 4.6.3
 func PowInt(a, b int) int {
@@ -115,3 +186,20 @@ func Minimum(numbers []int) (positions []int, val int) {
 	}
 	return pos, min
 }
+
+func MaximumPositions(numbers []int) (positions []int, val int) {
+	max := numbers[0]
+
+	pos := make([]int, 0, 5)
+	for idx := range numbers {
+		if numbers[idx] > max {
+			max = numbers[idx]
+		}
+	}
+	for idx := range numbers {
+		if numbers[idx] == max {
+			pos = append(pos, idx)
+		}
+	}
+	return pos, max
+}
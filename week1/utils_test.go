@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -64,3 +65,29 @@ func TestPatternToIndex(t *testing.T) {
 	assert.Equal(t, 912, PatternToIndexStr("ATGCAA"))
 	assert.Equal(t, 772508769, PatternToIndexStr("GTGAAGTGATACGAC"))
 }
+
+func TestParseFasta(t *testing.T) {
+	records, err := ParseFasta(strings.NewReader(">first record\r\nACGT\r\nTTGA\r\n\r\n>second\nGG\n>empty\n"))
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, ">first record", records[0].Raw())
+	assert.Equal(t, "first record", records[0].Name())
+	assert.Equal(t, "ACGTTTGA", records[0].Genome())
+	assert.Equal(t, "second", records[1].Name())
+	assert.Equal(t, "GG", records[1].Genome())
+	assert.Equal(t, "", records[2].Genome())
+
+	_, err = ParseFasta(strings.NewReader("ACGT\n>late header\n"))
+	assert.Error(t, err)
+}
+
+func TestReadFastaRecords(t *testing.T) {
+	records, err := ReadFastaRecords("fasta/25_S4.fasta")
+	assert.NoError(t, err)
+	assert.Len(t, records, 20)
+	for _, record := range records {
+		header, err := ParseSpadesHeader(record.Raw())
+		assert.NoError(t, err)
+		assert.Equal(t, header.Length, len(record.Genome()))
+	}
+}